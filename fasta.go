@@ -4,8 +4,17 @@ package fasta
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"iter"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Sequence is the common interface for a sequence that can be represented in
@@ -20,6 +29,12 @@ type Sequence interface {
 type Record struct {
 	Header   string
 	Sequence []byte
+
+	// Comments holds the text after ';' of the comment lines of the record,
+	// if any, as read by Reader.
+	Comments []string
+
+	lineWidth int // length of the first sequence line when read.
 }
 
 // Name returns the record header.
@@ -27,16 +42,130 @@ func (rec *Record) Name() string {
 	return rec.Header
 }
 
+// ID returns the record identifier, i.e. the header up to the first space or
+// tab. Leading whitespace is ignored.
+func (rec *Record) ID() string {
+	id, _ := splitHeader(rec.Header)
+	return id
+}
+
+// Description returns the record description, i.e. the header after the
+// identifier and the whitespace that follows it. It returns an empty string
+// if the header has no description.
+func (rec *Record) Description() string {
+	_, desc := splitHeader(rec.Header)
+	return desc
+}
+
+// splitHeader splits header into the identifier and the description on the
+// first run of spaces or tabs after any leading ones.
+func splitHeader(header string) (id, desc string) {
+	header = strings.TrimLeft(header, " \t")
+	i := strings.IndexAny(header, " \t")
+	if i < 0 {
+		return header, ""
+	}
+	return header[:i], strings.TrimLeft(header[i:], " \t")
+}
+
+// LineWidth returns the length of the first sequence line of the record as
+// read by Reader, or 0 if unknown, e.g. for records constructed directly.
+func (rec *Record) LineWidth() int {
+	return rec.lineWidth
+}
+
 // Seq returns the record sequence.
 func (rec *Record) Seq() []byte {
 	return rec.Sequence
 }
 
+// A FormatError reports malformed FASTA input and the line it was found at.
+type FormatError struct {
+	Line int    // 1-based line number.
+	Msg  string // description of the error.
+	Err  error  // sentinel error describing the failure, if any.
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("fasta: format error at line %d: %s", e.Line, e.Msg)
+}
+
+func (e *FormatError) Unwrap() error { return e.Err }
+
+// Errors returned by Reader and Writer. They are usually wrapped with
+// context such as the line number; test for them with errors.Is.
+var (
+	ErrNoHeader         = errors.New("fasta: format error: sequence before header")
+	ErrInvalidChar      = errors.New("fasta: format error: invalid character")
+	ErrLineTooLong      = errors.New("fasta: line exceeds max length")
+	ErrHeaderTooLong    = errors.New("fasta: header exceeds max length")
+	ErrChecksum         = errors.New("fasta: checksum mismatch")
+	ErrEmptyHeader      = errors.New("fasta: format error: empty header")
+	ErrDuplicateHeader  = errors.New("fasta: format error: duplicate header")
+	ErrEmptySequence    = errors.New("fasta: format error: empty sequence")
+	ErrInvalidSeparator = errors.New("fasta: invalid record separator")
+	ErrInvalidHeader    = errors.New("fasta: invalid header")
+	ErrInvalidSequence  = errors.New("fasta: invalid sequence")
+)
+
+// limitError reports a length limit being exceeded. It keeps its own message
+// while unwrapping to one of the sentinel errors above.
+type limitError struct {
+	msg string
+	err error
+}
+
+func (e *limitError) Error() string { return e.msg }
+func (e *limitError) Unwrap() error { return e.err }
+
 // A Reader reads FASTA encoded sequences.
 type Reader struct {
 	r   *bufio.Reader
 	err error
 	rec *Record
+
+	// buffered records served by Read in place of parsing r.
+	buf      []*Record
+	buffered bool
+
+	lineBuf  []byte              // buffer reused across lines.
+	line     int                 // number of lines consumed from r.
+	recLine  int                 // line of the header of rec.
+	unusual  bool                // whether rec was already reported for unusual bytes.
+	warnings []string            // non-fatal issues seen while reading.
+	alphabet *[256]bool          // valid sequence bytes, if not nil.
+	keep     func(*Record) bool  // records for which keep is false are skipped.
+	srcs     []io.Reader         // sources to read after r.
+	srcEnd   bool                // whether the end of a source was reached.
+	headers  map[string]int      // line of each header read, if Strict.
+	seen     map[string]struct{} // keys of records returned by a dedup reader.
+
+	// CollapseAmbiguous, if true, replaces the IUPAC ambiguity codes R, Y,
+	// S, W, K, M, B, D, H and V with N as sequences are read. Case is
+	// preserved.
+	CollapseAmbiguous bool
+
+	// VerifyChecksum, if true, verifies the " SHA256:<hex>" checksum that
+	// Writer.AppendChecksum appends to headers and strips it from the
+	// returned header. Read returns an error if the checksum does not match
+	// the sequence. Headers without a checksum are returned unchanged.
+	VerifyChecksum bool
+
+	// MaxHeaderLen, if positive, is the maximum length of a header. Read
+	// returns an error as soon as a longer header is encountered instead of
+	// buffering it, and keeps returning it on subsequent calls.
+	MaxHeaderLen int
+
+	// MaxLineLen, if positive, is the maximum length of a line. Read returns
+	// an error as soon as a longer line is encountered instead of buffering
+	// it, and keeps returning it on subsequent calls.
+	MaxLineLen int
+
+	// Strict, if true, disables lenient parsing: lines starting with ';' are
+	// read as sequence rather than skipped as comments, and Read returns a
+	// format error for an empty header, a header already seen in the stream
+	// or a record with an empty sequence.
+	Strict bool
 }
 
 // NewReader returns a new reader that reads from f.
@@ -44,99 +173,743 @@ func NewReader(f io.Reader) *Reader {
 	return &Reader{r: bufio.NewReader(f)}
 }
 
+// Reset discards any state of r and makes it read from f, reusing its
+// buffer. Options set on r, such as CollapseAmbiguous or the alphabet, are
+// kept while warnings, line counts and the records seen by a reader from
+// NewDedupReader start afresh.
+func (r *Reader) Reset(f io.Reader) {
+	if r.r == nil {
+		r.r = bufio.NewReader(f)
+	} else {
+		r.r.Reset(f)
+	}
+	r.err, r.rec = nil, nil
+	r.srcs, r.srcEnd = nil, false
+	r.buf, r.buffered = nil, false
+	r.line, r.recLine, r.unusual = 0, 0, false
+	r.warnings = nil
+	r.headers = nil
+	clear(r.seen)
+}
+
+// NewMultiReader returns a new reader that reads the sources fs one after the
+// other as a single stream of records. Unlike reading from an io.MultiReader,
+// the end of each source ends its last line and record, so a source without a
+// trailing newline does not merge with the first line of the next. Each
+// source must start with a header; line numbers in errors refer to the
+// current source.
+func NewMultiReader(fs ...io.Reader) *Reader {
+	if len(fs) == 0 {
+		return NewReader(strings.NewReader(""))
+	}
+	r := NewReader(fs[0])
+	r.srcs = append([]io.Reader(nil), fs[1:]...)
+	return r
+}
+
+// NewFilterReader returns a new reader that reads from f and returns only
+// the records for which keep returns true, skipping all others.
+func NewFilterReader(f io.Reader, keep func(*Record) bool) *Reader {
+	r := NewReader(f)
+	r.keep = keep
+	return r
+}
+
+// DedupKey selects what identifies duplicate records for NewDedupReader.
+type DedupKey int
+
+const (
+	DedupHeader   DedupKey = iota // records with the same header.
+	DedupSequence                 // records with the same sequence.
+	DedupBoth                     // records with the same header and sequence.
+)
+
+// NewDedupReader returns a new reader that reads from f and skips any record
+// whose key, as selected by by, was already returned. The first occurrence of
+// each key is returned. The keys of all returned records are kept in memory,
+// which for DedupSequence and DedupBoth includes all sequences.
+func NewDedupReader(f io.Reader, by DedupKey) *Reader {
+	r := NewReader(f)
+	r.seen = make(map[string]struct{})
+	r.keep = func(rec *Record) bool {
+		var key string
+		switch by {
+		case DedupHeader:
+			key = rec.Header
+		case DedupSequence:
+			key = string(rec.Sequence)
+		default:
+			// Headers do not contain newlines so the key is unambiguous.
+			key = rec.Header + "\n" + string(rec.Sequence)
+		}
+		if _, ok := r.seen[key]; ok {
+			return false
+		}
+		r.seen[key] = struct{}{}
+		return true
+	}
+	return r
+}
+
+// NewReaderGzip returns a new reader that reads from the gzip compressed
+// stream f. It returns an error if f does not start with a valid gzip header.
+func NewReaderGzip(f io.Reader) (*Reader, error) {
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(zr), nil
+}
+
+// NewReaderSize returns a new reader that reads from f using a buffer of at
+// least size bytes. A buffer large enough to hold typical lines avoids
+// repeated reallocation for files with very long lines.
+func NewReaderSize(f io.Reader, size int) *Reader {
+	return &Reader{r: bufio.NewReaderSize(f, size)}
+}
+
+// NewSortedReader returns a new reader that serves the records of f in the
+// order defined by less. Unlike NewReader, it reads and sorts the whole of f
+// up front and keeps all records in memory. Any error encountered while
+// reading f is returned. The sort is stable.
+func NewSortedReader(f io.Reader, less func(a, b *Record) bool) (*Reader, error) {
+	src := NewReader(f)
+	var recs []*Record
+	for {
+		rec, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	sort.SliceStable(recs, func(i, j int) bool { return less(recs[i], recs[j]) })
+	return &Reader{buf: recs, buffered: true}, nil
+}
+
 // Read returns a FASTA record from r. Read always returns either a non-nil
 // record or a non-nil error, but not both. After reaching EOF, subsequent
 // calls to Read will return a nil record and io.EOF.
 func (r *Reader) Read() (*Record, error) {
-	// Keep returning EOF after EOF reached.
-	if r.err == io.EOF {
-		return nil, io.EOF
+	return r.ReadContext(context.Background())
+}
+
+// ReadContext is like Read but returns ctx.Err() if ctx is done before the
+// record is complete. The context is checked before each line is read so a
+// blocked read of the underlying reader is not interrupted. A reader
+// interrupted this way can resume with a later call.
+func (r *Reader) ReadContext(ctx context.Context) (*Record, error) {
+	for {
+		rec, err := r.read(ctx)
+		if err != nil || r.keep == nil || r.keep(rec) {
+			return rec, err
+		}
+	}
+}
+
+// read returns the next FASTA record from r regardless of r.keep.
+func (r *Reader) read(ctx context.Context) (*Record, error) {
+	if r.buffered {
+		if len(r.buf) == 0 {
+			return nil, io.EOF
+		}
+		rec := r.buf[0]
+		r.buf[0] = nil
+		r.buf = r.buf[1:]
+		return rec, nil
 	}
 
 	for {
-		line, err := r.r.ReadBytes('\n')
-		if err != nil {
-			if err != io.EOF {
-				return nil, err
+		if r.err != nil && r.err != io.EOF { // input rejected by a limit.
+			return nil, r.err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Return the last record once the end of the input, or of one of the
+		// sources of a multi reader, is reached.
+		if r.err == io.EOF || r.srcEnd {
+			if r.srcEnd {
+				r.srcEnd, r.line = false, 0
 			}
-			// If no newline at end of file.
-			if len(line) > 0 {
-				r.rec.Sequence = append(r.rec.Sequence, line...)
+			if r.rec == nil { // no records.
+				if r.err == io.EOF {
+					return nil, io.EOF
+				}
+				continue
 			}
-			r.err = io.EOF
-			return r.rec, nil
+			cerr := r.checkRecord()
+			rec := r.rec
+			r.rec = nil
+			if cerr != nil {
+				return nil, cerr
+			}
+			return rec, nil
 		}
 
+		line, err := r.readLine()
+		if err == io.EOF {
+			if len(r.srcs) > 0 {
+				r.r.Reset(r.srcs[0])
+				r.srcs[0] = nil
+				r.srcs = r.srcs[1:]
+				r.srcEnd = true
+			} else {
+				r.err = io.EOF
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 { // nothing left at end of file.
+			continue
+		}
+		r.line++
+
 		line = bytes.TrimSpace(line)
 		if len(line) == 0 { // Skip empty lines.
 			continue
 		}
 
+		if line[0] == ';' && !r.Strict { // Skip comment lines.
+			if r.rec != nil {
+				r.rec.Comments = append(r.rec.Comments, string(line[1:]))
+			}
+			continue
+		}
+
 		if line[0] != '>' {
 			if r.rec == nil { // reached sequence before the first header.
-				return nil, errors.New("fasta: format error: sequence before header")
+				return nil, &FormatError{Line: r.line, Msg: "sequence before header", Err: ErrNoHeader}
 			}
-			r.rec.Sequence = append(r.rec.Sequence, line...)
+			if err := r.checkAlphabet(line); err != nil {
+				return nil, err
+			}
+			r.checkSeq(line)
+			if r.rec.lineWidth == 0 {
+				r.rec.lineWidth = len(line)
+			}
+			r.rec.Sequence = r.appendSeq(r.rec.Sequence, line)
 			continue
 		}
+		cerr := r.checkRecord()
 		temp := r.rec
 		r.rec = &Record{
 			Header:   string(line[1:]),
 			Sequence: make([]byte, 0),
 		}
+		r.recLine, r.unusual = r.line, false
+		if len(r.rec.Header) > longHeaderLen {
+			r.warnf("header of %d bytes", len(r.rec.Header))
+		}
+		if cerr != nil {
+			return nil, cerr
+		}
 		if temp != nil {
 			return temp, nil
 		}
 	}
 }
 
+// Predefined alphabets for Reader.SetAlphabet. They include the IUPAC
+// ambiguity codes and the gap '-'.
+var (
+	DNA     = []byte("ACGTRYSWKMBDHVN-")
+	RNA     = []byte("ACGURYSWKMBDHVN-")
+	Protein = []byte("ACDEFGHIKLMNPQRSTVWYBZJUOX*-")
+)
+
+// SetAlphabet restricts the sequences read by r to the bytes in valid,
+// ignoring case. Read returns a format error for a sequence containing any
+// other byte. A nil valid removes the restriction.
+func (r *Reader) SetAlphabet(valid []byte) {
+	if valid == nil {
+		r.alphabet = nil
+		return
+	}
+	r.alphabet = new([256]bool)
+	for _, c := range valid {
+		r.alphabet[c] = true
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' {
+			r.alphabet[c^0x20] = true
+		}
+	}
+}
+
+// checkAlphabet returns an error if the sequence line contains a byte not in
+// the alphabet of r.
+func (r *Reader) checkAlphabet(line []byte) error {
+	if r.alphabet == nil {
+		return nil
+	}
+	for _, c := range line {
+		if !r.alphabet[c] {
+			return &FormatError{Line: r.line, Msg: fmt.Sprintf("invalid character %q in %q", c, r.rec.Header), Err: ErrInvalidChar}
+		}
+	}
+	return nil
+}
+
+// All returns an iterator over the records of r. Each record is yielded with
+// a nil error. Iteration stops at EOF or, after yielding a nil record and the
+// error, at the first error.
+func (r *Reader) All() iter.Seq2[*Record, error] {
+	return func(yield func(*Record, error) bool) {
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(rec, nil) {
+				return
+			}
+		}
+	}
+}
+
+// readLine returns the next line of r including the trailing newline, if
+// any. The returned slice is only valid until the next call. It returns an
+// error if the line is longer than MaxLineLen or a header longer than
+// MaxHeaderLen.
+func (r *Reader) readLine() ([]byte, error) {
+	line := r.lineBuf[:0]
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		r.lineBuf = line
+		if r.MaxLineLen > 0 && len(bytes.TrimSpace(line)) > r.MaxLineLen {
+			r.err = fmt.Errorf("%w of %d bytes at line %d", ErrLineTooLong, r.MaxLineLen, r.line+1)
+			return nil, r.err
+		}
+		if r.MaxHeaderLen > 0 {
+			if h := bytes.TrimSpace(line); len(h) > 0 && h[0] == '>' && len(h)-1 > r.MaxHeaderLen {
+				r.err = &limitError{
+					msg: fmt.Sprintf("fasta: header exceeds %d bytes at line %d", r.MaxHeaderLen, r.line+1),
+					err: ErrHeaderTooLong,
+				}
+				return nil, r.err
+			}
+		}
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// longHeaderLen is the header length above which Reader emits a warning.
+const longHeaderLen = 1000
+
+// Warnings returns the non-fatal issues seen so far while reading: records
+// with an empty sequence, sequences containing unusual bytes and very long
+// headers. Each warning includes the line it refers to. Reading is not
+// interrupted by these issues.
+func (r *Reader) Warnings() []string {
+	return r.warnings
+}
+
+// warnf records a warning for the current line.
+func (r *Reader) warnf(format string, args ...interface{}) {
+	r.warnings = append(r.warnings, fmt.Sprintf("fasta: line %d: ", r.line)+fmt.Sprintf(format, args...))
+}
+
+// checkSeq records a warning if the sequence line contains a byte other than
+// a letter, '-', '*' or '.'. Only the first such line of each record is
+// reported.
+func (r *Reader) checkSeq(line []byte) {
+	if r.unusual || r.rec == nil {
+		return
+	}
+	for _, c := range line {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || c == '-' || c == '*' || c == '.' {
+			continue
+		}
+		r.unusual = true
+		r.warnf("unusual character %q in sequence of %q", c, r.rec.Header)
+		return
+	}
+}
+
+// checkRecord runs the checks that need the complete current record. It
+// records a warning if the record has an empty sequence and, if
+// VerifyChecksum is set, verifies and strips the header checksum.
+func (r *Reader) checkRecord() error {
+	if r.rec == nil {
+		return nil
+	}
+	if len(r.rec.Sequence) == 0 {
+		if r.Strict {
+			return &FormatError{Line: r.recLine, Msg: fmt.Sprintf("empty sequence for %q", r.rec.Header), Err: ErrEmptySequence}
+		}
+		r.warnings = append(r.warnings, fmt.Sprintf("fasta: line %d: empty sequence for %q", r.recLine, r.rec.Header))
+	}
+	if r.VerifyChecksum {
+		if err := verifyChecksum(r.rec); err != nil {
+			return err
+		}
+	}
+	if r.Strict {
+		return r.checkHeader()
+	}
+	return nil
+}
+
+// checkHeader returns an error if the header of rec, after any checksum is
+// stripped, is empty or was already read from r.
+func (r *Reader) checkHeader() error {
+	h := r.rec.Header
+	if h == "" {
+		return &FormatError{Line: r.recLine, Msg: "empty header", Err: ErrEmptyHeader}
+	}
+	if prev, ok := r.headers[h]; ok {
+		return &FormatError{Line: r.recLine, Msg: fmt.Sprintf("duplicate header %q, first seen at line %d", h, prev), Err: ErrDuplicateHeader}
+	}
+	if r.headers == nil {
+		r.headers = make(map[string]int)
+	}
+	r.headers[h] = r.recLine
+	return nil
+}
+
+// checksumPrefix precedes the hex encoded SHA-256 of the sequence that
+// Writer.AppendChecksum appends to headers.
+const checksumPrefix = " SHA256:"
+
+// checksum returns the hex encoded SHA-256 of seq.
+func checksum(seq []byte) string {
+	sum := sha256.Sum256(seq)
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyChecksum checks the SHA-256 checksum at the end of the header of rec
+// against its sequence and strips it from the header. Headers without a
+// checksum are left as they are.
+func verifyChecksum(rec *Record) error {
+	i := strings.LastIndex(rec.Header, checksumPrefix)
+	if i < 0 || len(rec.Header)-i-len(checksumPrefix) != sha256.Size*2 {
+		return nil
+	}
+	want := rec.Header[i+len(checksumPrefix):]
+	rec.Header = rec.Header[:i]
+	if checksum(rec.Sequence) != want {
+		return fmt.Errorf("%w for %q", ErrChecksum, rec.Header)
+	}
+	return nil
+}
+
+// appendSeq appends the sequence line to seq applying any conversions
+// configured on r.
+func (r *Reader) appendSeq(seq, line []byte) []byte {
+	n := len(seq)
+	seq = append(seq, line...)
+	if r.CollapseAmbiguous {
+		collapseAmbiguous(seq[n:])
+	}
+	return seq
+}
+
+// collapseAmbiguous replaces in place the IUPAC ambiguity codes in b with N,
+// preserving case.
+func collapseAmbiguous(b []byte) {
+	for i, c := range b {
+		switch c {
+		case 'R', 'Y', 'S', 'W', 'K', 'M', 'B', 'D', 'H', 'V':
+			b[i] = 'N'
+		case 'r', 'y', 's', 'w', 'k', 'm', 'b', 'd', 'h', 'v':
+			b[i] = 'n'
+		}
+	}
+}
+
 // A Writer writes sequences in a FASTA format.
 type Writer struct {
 	w     io.Writer
 	width int
+
+	// RecordSeparator, if not empty, is written verbatim after the sequence
+	// of each record, e.g. "//\n" or "\n" for a blank line. Separators not
+	// ending in a newline, which would join the next header line, or having
+	// a line whose first non-whitespace byte is '>' are rejected by Write
+	// since they would be parsed back as part of a record.
+	RecordSeparator string
+
+	// AppendChecksum, if true, appends " SHA256:<hex>" with the SHA-256 of
+	// the sequence to each written header. See Reader.VerifyChecksum.
+	AppendChecksum bool
+
+	// UniquifyHeaders, if true, makes written headers unique by appending
+	// ".1", ".2", etc. to a header that was already written. See Renames.
+	UniquifyHeaders bool
+
+	// Lowercase, if true, writes sequences in lowercase. Headers are not
+	// affected.
+	Lowercase bool
+
+	// NoTrailingNewline, if true and RecordSeparator is empty, omits the
+	// newline after the last line of the last record. The newline that ends
+	// each record is only written when the next record starts.
+	NoTrailingNewline bool
+
+	buf     []byte              // output buffer reused across records.
+	newline bool                // whether the newline ending the last record is pending.
+	pos     int                 // sequence bytes written for current record.
+	wrap    int                 // line width for current record.
+	closer  io.Closer           // closed by Close, if not nil.
+	seen    map[string]int      // written headers and their repeat count.
+	renames map[string][]string // renamed headers.
 }
 
-// NewWriter returns a new FASTA format writer that writes to w.
+// WidthPreserve is a Writer width that wraps each record at its LineWidth,
+// i.e. as it was wrapped when read, or at DefaultWidth if not known.
+const WidthPreserve = -2
+
+// DefaultWidth is the line width used with WidthPreserve for records of
+// unknown line width.
+const DefaultWidth = 60
+
+// NewWriter returns a new FASTA format writer that writes to w. Sequences are
+// wrapped at width letters per line. If width is WidthPreserve, each record
+// is wrapped at its own line width. Otherwise, if width <= 0 sequences are
+// not wrapped and each is written on a single line.
 func NewWriter(w io.Writer, width int) *Writer {
-	if width == 0 {
-		width = 1
-	}
 	return &Writer{
 		w:     w,
 		width: width,
 	}
 }
 
+// NewWriterGzip returns a new FASTA format writer that writes gzip compressed
+// output to w. Close must be called after the last record to flush the
+// compressed stream; it does not close w.
+func NewWriterGzip(w io.Writer, width int) *Writer {
+	zw := gzip.NewWriter(w)
+	fw := NewWriter(zw, width)
+	fw.closer = zw
+	return fw
+}
+
+// Close flushes and closes the compressed stream of a writer returned by
+// NewWriterGzip. It does not close the underlying writer. For other writers it
+// does nothing.
+func (w *Writer) Close() error {
+	if w.closer == nil {
+		return nil
+	}
+	return w.closer.Close()
+}
+
 // Write writes a single sequence in w. It return the number of bytes written
-// and any error.
+// and any error. Sequences whose header or sequence contain a newline, or
+// whose sequence would have a line starting with '>' or ';', are rejected
+// without writing anything. The record is assembled in a buffer reused across
+// calls and passed to w in a single call, or in chunks of about 64KB for long
+// sequences.
 func (w *Writer) Write(s Sequence) (n int, err error) {
 	var (
 		_n int
 	)
 
+	if err = checkSeparator(w.RecordSeparator); err != nil {
+		return 0, err
+	}
+	if strings.ContainsAny(s.Name(), "\r\n") {
+		return 0, fmt.Errorf("%w: contains newline", ErrInvalidHeader)
+	}
+	w.wrap = w.recordWidth(s)
+	if err = w.checkSeq(s.Seq(), 0); err != nil {
+		return 0, err
+	}
+
 	// Write the header.
-	n, err = w.w.Write([]byte(">" + s.Name()))
+	name := s.Name()
+	if w.UniquifyHeaders {
+		name = w.unique(name)
+	}
+	if w.AppendChecksum {
+		seq := s.Seq()
+		if w.Lowercase {
+			seq = append([]byte(nil), seq...)
+			toLower(seq)
+		}
+		name += checksumPrefix + checksum(seq)
+	}
+	n, err = w.writeHeader(name)
 	if err != nil {
 		return n, err
 	}
 
-	// Write the sequence (width letters at each line).
-	for i := 0; i < len(s.Seq()); i++ {
-		if i%w.width == 0 {
-			_n, err = w.w.Write([]byte("\n"))
+	// Write the sequence.
+	_n, err = w.writeSeq(s.Seq())
+	if n += _n; err != nil {
+		return n, err
+	}
+	_n, err = w.endRecord()
+	if n += _n; err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// recordWidth returns the line width for writing s.
+func (w *Writer) recordWidth(s Sequence) int {
+	if w.width != WidthPreserve {
+		return w.width
+	}
+	if lw, ok := s.(interface{ LineWidth() int }); ok && lw.LineWidth() > 0 {
+		return lw.LineWidth()
+	}
+	return DefaultWidth
+}
+
+// WriteString writes a single sequence with the given header and sequence in
+// w, exactly as Write would for the equivalent Record.
+func (w *Writer) WriteString(header, seq string) (int, error) {
+	return w.Write(&Record{Header: header, Sequence: []byte(seq)})
+}
+
+// checkSeq returns an error if seq contains a newline or if, written at
+// position pos of the sequence of a record, a '>' or ';' would start a line
+// and be read back as a header or comment.
+func (w *Writer) checkSeq(seq []byte, pos int) error {
+	for i, c := range seq {
+		switch {
+		case c == '\n' || c == '\r':
+			return fmt.Errorf("%w: contains newline", ErrInvalidSequence)
+		case (c == '>' || c == ';') && (pos+i == 0 || w.wrap > 0 && (pos+i)%w.wrap == 0):
+			return fmt.Errorf("%w: %q at start of line", ErrInvalidSequence, c)
+		}
+	}
+	return nil
+}
+
+// writeBufSize is the size above which Writer flushes its buffer while
+// writing a sequence.
+const writeBufSize = 64 << 10
+
+// writeHeader starts a new record by buffering its header line without the
+// terminating newline, which is written along with the sequence.
+func (w *Writer) writeHeader(name string) (int, error) {
+	w.pos = 0
+	if w.newline {
+		w.buf = append(w.buf, '\n')
+		w.newline = false
+	}
+	w.buf = append(w.buf, '>')
+	w.buf = append(w.buf, name...)
+	return 0, nil
+}
+
+// writeSeq buffers seq as the continuation of the sequence of the current
+// record, width letters at each line or all on one line if width <= 0,
+// flushing the buffer to w whenever it grows larger than writeBufSize.
+func (w *Writer) writeSeq(seq []byte) (n int, err error) {
+	for len(seq) > 0 {
+		k := len(seq)
+		if w.wrap > 0 {
+			k = w.wrap - w.pos%w.wrap
+		}
+		if w.pos == 0 || w.wrap > 0 && w.pos%w.wrap == 0 {
+			w.buf = append(w.buf, '\n')
+		}
+		if k > len(seq) {
+			k = len(seq)
+		}
+		start := len(w.buf)
+		w.buf = append(w.buf, seq[:k]...)
+		if w.Lowercase {
+			toLower(w.buf[start:])
+		}
+		w.pos += k
+		seq = seq[k:]
+
+		if len(w.buf) >= writeBufSize {
+			var _n int
+			_n, err = w.flush()
 			if n += _n; err != nil {
 				return n, err
 			}
 		}
-		_n, err = w.w.Write([]byte{s.Seq()[i]})
-		if n += _n; err != nil {
-			return n, err
+	}
+	return n, nil
+}
+
+// endRecord terminates the current record followed by the record separator
+// and flushes the buffer to w.
+func (w *Writer) endRecord() (int, error) {
+	if w.NoTrailingNewline && w.RecordSeparator == "" {
+		w.newline = true
+	} else {
+		w.buf = append(w.buf, '\n')
+	}
+	w.buf = append(w.buf, w.RecordSeparator...)
+	return w.flush()
+}
+
+// flush writes the buffered bytes to w and empties the buffer.
+func (w *Writer) flush() (int, error) {
+	n, err := w.w.Write(w.buf)
+	w.buf = w.buf[:0]
+	return n, err
+}
+
+// toLower converts in place the ASCII upper case letters of b to lower case.
+func toLower(b []byte) {
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
 		}
 	}
-	_n, err = w.w.Write([]byte("\n"))
-	if n += _n; err != nil {
-		return n, err
+}
+
+// Renames returns, for each header that UniquifyHeaders renamed, the headers
+// its repeats were written as, in order. The first occurrence of a header is
+// written unchanged and is not included.
+func (w *Writer) Renames() map[string][]string {
+	return w.renames
+}
+
+// unique returns name, if not written before, or name suffixed with the next
+// free ".N" counter, and marks the result as written.
+func (w *Writer) unique(name string) string {
+	if w.seen == nil {
+		w.seen = make(map[string]int)
+		w.renames = make(map[string][]string)
+	}
+	out := name
+	for {
+		if _, ok := w.seen[out]; !ok {
+			break
+		}
+		w.seen[name]++
+		out = name + "." + strconv.Itoa(w.seen[name])
 	}
+	w.seen[out] = 0
+	if out != name {
+		w.renames[name] = append(w.renames[name], out)
+	}
+	return out
+}
 
-	return n, nil
+// checkSeparator returns an error if sep, written after a record, would be
+// read back as part of a record: if it does not end in a newline or if any of
+// its lines starts with '>' after leading whitespace.
+func checkSeparator(sep string) error {
+	if sep == "" {
+		return nil
+	}
+	if !strings.HasSuffix(sep, "\n") {
+		return fmt.Errorf("%w: missing trailing newline", ErrInvalidSeparator)
+	}
+	for _, line := range strings.Split(sep, "\n") {
+		if strings.HasPrefix(strings.TrimLeft(line, " \t\r\v\f"), ">") {
+			return fmt.Errorf("%w: line starts with '>'", ErrInvalidSeparator)
+		}
+	}
+	return nil
 }
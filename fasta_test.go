@@ -2,18 +2,54 @@ package fasta
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"reflect"
 	"strings"
 	"testing"
 )
 
+// Test ID and Description
+var headerTests = []struct {
+	Test        string
+	Header      string
+	ID          string
+	Description string
+}{
+	{"empty", "", "", ""},
+	{"id only", "chr1", "chr1", ""},
+	{"description", "chr1 Homo sapiens chromosome 1", "chr1", "Homo sapiens chromosome 1"},
+	{"tab", "chr1\t \tHomo sapiens", "chr1", "Homo sapiens"},
+	{"leading whitespace", "  chr1 desc", "chr1", "desc"},
+	{"trailing whitespace", "chr1 ", "chr1", ""},
+	{"whitespace only", " \t", "", ""},
+}
+
+func TestIDDescription(t *testing.T) {
+	for _, tt := range headerTests {
+		rec := &Record{Header: tt.Header}
+		if rec.ID() != tt.ID {
+			t.Errorf("%s: id=%q want %q", tt.Test, rec.ID(), tt.ID)
+		}
+		if rec.Description() != tt.Description {
+			t.Errorf("%s: description=%q want %q", tt.Test, rec.Description(), tt.Description)
+		}
+		if rec.Name() != tt.Header {
+			t.Errorf("%s: name=%q want %q", tt.Test, rec.Name(), tt.Header)
+		}
+	}
+}
+
 // Test Read
 var readTests = []struct {
 	Test    string
 	Data    string
 	Err     string
+	Line    int
 	Headers []string
 	Seqs    []string
 }{
@@ -50,13 +86,52 @@ var readTests = []struct {
 		Headers: []string{"Seq1", "Seq2"},
 		Seqs:    []string{"AAABBB", "CCCDDD"},
 	},
+	{
+		Test: "no newline trailing whitespace",
+		Data: "" +
+			">Seq1\r\n" +
+			"AAA\r\n" +
+			"BBB \r",
+		Headers: []string{"Seq1"},
+		Seqs:    []string{"AAABBB"},
+	},
+	{
+		Test: "no newline header",
+		Data: "" +
+			">Seq1\n" +
+			"AAA\n" +
+			">Seq2 ",
+		Headers: []string{"Seq1", "Seq2"},
+		Seqs:    []string{"AAA", ""},
+	},
+	{
+		Test:    "empty",
+		Data:    "",
+		Headers: []string{},
+	},
+	{
+		Test:    "blank lines",
+		Data:    "\n\n  \n",
+		Headers: []string{},
+	},
+	{
+		Test:    "format error no newline",
+		Data:    "AAA",
+		Err:     "fasta: format error at line 1: sequence before header",
+		Line:    1,
+		Headers: []string{""},
+		Seqs:    []string{""},
+	},
 	{
 		Test: "format error",
 		Data: "" +
+			"\n" +
+			"  \n" +
 			"AAA\n" +
 			">Seq1\n" +
 			"BBB\n",
-		Err:     "fasta: format error: sequence before header",
+		Err:     "fasta: format error at line 3: sequence before header",
+		Line:    3,
 		Headers: []string{""},
 		Seqs:    []string{""},
 	},
@@ -73,6 +148,10 @@ func TestRead(t *testing.T) {
 				if err == nil || !strings.Contains(err.Error(), tt.Err) {
 					t.Errorf("%s: error %q, want error %q", tt.Test, err.Error(), tt.Err)
 				}
+				var ferr *FormatError
+				if tt.Line != 0 && (!errors.As(err, &ferr) || ferr.Line != tt.Line) {
+					t.Errorf("%s: error %#v, want format error at line %d", tt.Test, err, tt.Line)
+				}
 				continue
 			} else if err != nil {
 				t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
@@ -91,6 +170,622 @@ func TestRead(t *testing.T) {
 				t.Errorf("%s: seq=%q want %q", tt.Test, string(rec.Seq()), tt.Seqs[recIdx])
 			}
 		}
+
+		if tt.Err == "" {
+			if rec, err := r.Read(); rec != nil || err != io.EOF {
+				t.Errorf("%s: got %v, %v want nil, io.EOF", tt.Test, rec, err)
+			}
+		}
+	}
+}
+
+func TestReadComments(t *testing.T) {
+	in := "" +
+		";file comment\n" +
+		">Seq1\n" +
+		";comment 1\n" +
+		"AAA\n" +
+		"  ; comment 2\n" +
+		"BBB\n" +
+		">Seq2\n" +
+		"CCC\n"
+
+	r := NewReader(strings.NewReader(in))
+	var tests = []struct {
+		Header   string
+		Seq      string
+		Comments []string
+	}{
+		{"Seq1", "AAABBB", []string{"comment 1", " comment 2"}},
+		{"Seq2", "CCC", nil},
+	}
+	for _, tt := range tests {
+		rec, err := r.Read()
+		if err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+		if rec.Name() != tt.Header || string(rec.Seq()) != tt.Seq {
+			t.Errorf("got %q %q want %q %q", rec.Name(), rec.Seq(), tt.Header, tt.Seq)
+		}
+		if !reflect.DeepEqual(rec.Comments, tt.Comments) {
+			t.Errorf("%s: comments=%q want %q", tt.Header, rec.Comments, tt.Comments)
+		}
+	}
+
+	// Strict mode reads comments as sequence.
+	r = NewReader(strings.NewReader(in[len(";file comment\n"):]))
+	r.Strict = true
+	rec, err := r.Read()
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	if want := ";comment 1AAA; comment 2BBB"; string(rec.Seq()) != want {
+		t.Errorf("seq=%q want %q", rec.Seq(), want)
+	}
+}
+
+func TestReadStrict(t *testing.T) {
+	var tests = []struct {
+		Test     string
+		Data     string
+		Checksum bool
+		Headers  []string
+		Err      string
+		Sentinel error
+	}{
+		{
+			Test:    "clean",
+			Data:    ">Seq1\nAAA\n>Seq2\nCCC\n",
+			Headers: []string{"Seq1", "Seq2"},
+		},
+		{
+			Test:     "empty header",
+			Data:     ">Seq1\nAAA\n>\nCCC\n",
+			Headers:  []string{"Seq1"},
+			Err:      "fasta: format error at line 3: empty header",
+			Sentinel: ErrEmptyHeader,
+		},
+		{
+			Test:     "duplicate header",
+			Data:     ">Seq1\nAAA\n>Seq2\nCCC\n>Seq1\nGGG\n",
+			Headers:  []string{"Seq1", "Seq2"},
+			Err:      `fasta: format error at line 5: duplicate header "Seq1", first seen at line 1`,
+			Sentinel: ErrDuplicateHeader,
+		},
+		{
+			Test:     "duplicate header with checksums",
+			Data:     ">a SHA256:" + checksum([]byte("AAA")) + "\nAAA\n>a SHA256:" + checksum([]byte("CCC")) + "\nCCC\n",
+			Checksum: true,
+			Headers:  []string{"a"},
+			Err:      `fasta: format error at line 3: duplicate header "a", first seen at line 1`,
+			Sentinel: ErrDuplicateHeader,
+		},
+		{
+			Test:     "checksums",
+			Data:     ">a SHA256:" + checksum([]byte("AAA")) + "\nAAA\n>b SHA256:" + checksum([]byte("CCC")) + "\nCCC\n",
+			Checksum: true,
+			Headers:  []string{"a", "b"},
+		},
+		{
+			Test:     "empty sequence",
+			Data:     ">Seq1\nAAA\n>Seq2\n\n>Seq3\nCCC\n",
+			Headers:  []string{"Seq1"},
+			Err:      `fasta: format error at line 3: empty sequence for "Seq2"`,
+			Sentinel: ErrEmptySequence,
+		},
+		{
+			Test:     "empty last sequence",
+			Data:     ">Seq1\nAAA\n>Seq2\n",
+			Headers:  []string{"Seq1"},
+			Err:      `fasta: format error at line 3: empty sequence for "Seq2"`,
+			Sentinel: ErrEmptySequence,
+		},
+	}
+
+	for _, tt := range tests {
+		// Non-strict reading accepts all of the input.
+		if _, err := ReadAll(strings.NewReader(tt.Data)); err != nil {
+			t.Errorf("%s: unexpected non-strict error %q", tt.Test, err.Error())
+		}
+
+		r := NewReader(strings.NewReader(tt.Data))
+		r.Strict = true
+		r.VerifyChecksum = tt.Checksum
+		var headers []string
+		var err error
+		for {
+			var rec *Record
+			if rec, err = r.Read(); err != nil {
+				break
+			}
+			headers = append(headers, rec.Header)
+		}
+		if !reflect.DeepEqual(headers, tt.Headers) {
+			t.Errorf("%s: headers=%q want %q", tt.Test, headers, tt.Headers)
+		}
+		if tt.Err == "" {
+			if err != io.EOF {
+				t.Errorf("%s: unexpected error %v", tt.Test, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.Err {
+			t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+		}
+		if !errors.Is(err, tt.Sentinel) {
+			t.Errorf("%s: error %v, want errors.Is %q", tt.Test, err, tt.Sentinel)
+		}
+	}
+}
+
+func TestReadCollapseAmbiguous(t *testing.T) {
+	var tests = []struct {
+		Test     string
+		Data     string
+		Collapse bool
+		Seq      string
+	}{
+		{"off", ">Seq1\nACRYgt\nswkmBDHVU\n", false, "ACRYgtswkmBDHVU"},
+		{"on", ">Seq1\nACRYgt\nswkmBDHVU\n", true, "ACNNgtnnnnNNNNU"},
+		{"on no newline", ">Seq1\nACRYgt\nswkmBDHVU", true, "ACNNgtnnnnNNNNU"},
+	}
+
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(tt.Data))
+		r.CollapseAmbiguous = tt.Collapse
+
+		rec, err := r.Read()
+		if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+		if string(rec.Seq()) != tt.Seq {
+			t.Errorf("%s: seq=%q want %q", tt.Test, string(rec.Seq()), tt.Seq)
+		}
+	}
+}
+
+func TestNewMultiReader(t *testing.T) {
+	var tests = []struct {
+		Test    string
+		Sources []string
+		Err     string
+		Headers []string
+		Seqs    []string
+	}{
+		{
+			Test:    "none",
+			Sources: nil,
+		},
+		{
+			Test:    "no trailing newline",
+			Sources: []string{">Seq1\nAAA\nBBB", ">Seq2\nCCC\n"},
+			Headers: []string{"Seq1", "Seq2"},
+			Seqs:    []string{"AAABBB", "CCC"},
+		},
+		{
+			Test:    "header without newline",
+			Sources: []string{">Seq1\nAAA\n>Seq2", "", ">Seq3\nCCC", "\n"},
+			Headers: []string{"Seq1", "Seq2", "Seq3"},
+			Seqs:    []string{"AAA", "", "CCC"},
+		},
+		{
+			Test:    "sequence at start of source",
+			Sources: []string{">Seq1\nAAA\n", "\nCCC\n"},
+			Err:     "fasta: format error at line 2: sequence before header",
+			Headers: []string{"Seq1"},
+			Seqs:    []string{"AAA"},
+		},
+	}
+
+	for _, tt := range tests {
+		var fs []io.Reader
+		for _, src := range tt.Sources {
+			fs = append(fs, strings.NewReader(src))
+		}
+		r := NewMultiReader(fs...)
+
+		var (
+			headers, seqs []string
+			err           error
+		)
+		for {
+			var rec *Record
+			if rec, err = r.Read(); err != nil {
+				break
+			}
+			headers = append(headers, rec.Name())
+			seqs = append(seqs, string(rec.Seq()))
+		}
+
+		if tt.Err != "" {
+			if err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+		} else if err != io.EOF {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+		if !reflect.DeepEqual(headers, tt.Headers) {
+			t.Errorf("%s: headers=%q want %q", tt.Test, headers, tt.Headers)
+		}
+		if !reflect.DeepEqual(seqs, tt.Seqs) {
+			t.Errorf("%s: seqs=%q want %q", tt.Test, seqs, tt.Seqs)
+		}
+	}
+}
+
+func TestNewFilterReader(t *testing.T) {
+	in := ">Seq1 human\nAAAA\n>Seq2 mouse\nCC\n>Seq3 human\nG\n>Seq4 mouse\nTTTTT\n"
+	var tests = []struct {
+		Test    string
+		Data    string
+		Keep    func(*Record) bool
+		Err     string
+		Headers []string
+	}{
+		{
+			Test:    "min length",
+			Data:    in,
+			Keep:    func(rec *Record) bool { return len(rec.Seq()) >= 3 },
+			Headers: []string{"Seq1 human", "Seq4 mouse"},
+		},
+		{
+			Test:    "header substring",
+			Data:    in,
+			Keep:    func(rec *Record) bool { return strings.Contains(rec.Name(), "human") },
+			Headers: []string{"Seq1 human", "Seq3 human"},
+		},
+		{
+			Test:    "none",
+			Data:    in,
+			Keep:    func(rec *Record) bool { return false },
+			Headers: nil,
+		},
+		{
+			Test:    "error after skipped",
+			Data:    ">Seq1\nA\n>Seq2\nCCC\n>Seq3\nG\n",
+			Keep:    func(rec *Record) bool { return len(rec.Seq()) >= 3 },
+			Err:     "fasta: format error at line 6: invalid character 'G' in \"Seq3\"",
+			Headers: []string{"Seq2"},
+		},
+	}
+
+	for _, tt := range tests {
+		r := NewFilterReader(strings.NewReader(tt.Data), tt.Keep)
+		if tt.Err != "" {
+			r.SetAlphabet([]byte("AC"))
+		}
+
+		var (
+			headers []string
+			err     error
+		)
+		for {
+			var rec *Record
+			if rec, err = r.Read(); err != nil {
+				break
+			}
+			headers = append(headers, rec.Name())
+		}
+
+		if tt.Err != "" {
+			if err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+		} else if err != io.EOF {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+		if !reflect.DeepEqual(headers, tt.Headers) {
+			t.Errorf("%s: headers=%q want %q", tt.Test, headers, tt.Headers)
+		}
+	}
+}
+
+func TestNewDedupReader(t *testing.T) {
+	in := ">a\nAAA\n>b\nAAA\n>a\nCCC\n>c\nGGG\n>a\nAAA\n"
+	var tests = []struct {
+		Test    string
+		By      DedupKey
+		Headers []string
+		Seqs    []string
+	}{
+		{"header", DedupHeader, []string{"a", "b", "c"}, []string{"AAA", "AAA", "GGG"}},
+		{"sequence", DedupSequence, []string{"a", "a", "c"}, []string{"AAA", "CCC", "GGG"}},
+		{"both", DedupBoth, []string{"a", "b", "a", "c"}, []string{"AAA", "AAA", "CCC", "GGG"}},
+	}
+
+	for _, tt := range tests {
+		r := NewDedupReader(strings.NewReader(in), tt.By)
+
+		var headers, seqs []string
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error %q", tt.Test, err.Error())
+			}
+			headers = append(headers, rec.Name())
+			seqs = append(seqs, string(rec.Seq()))
+		}
+
+		if !reflect.DeepEqual(headers, tt.Headers) {
+			t.Errorf("%s: headers=%q want %q", tt.Test, headers, tt.Headers)
+		}
+		if !reflect.DeepEqual(seqs, tt.Seqs) {
+			t.Errorf("%s: seqs=%q want %q", tt.Test, seqs, tt.Seqs)
+		}
+	}
+}
+
+func TestReaderReset(t *testing.T) {
+	r := NewReader(strings.NewReader(">Seq1\nAAA\n>Seq2\n\nCCC"))
+	for {
+		if _, err := r.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+	}
+
+	r.Reset(strings.NewReader(">Seq3\nGGG\n>Seq4\nTTT\n"))
+	for _, want := range []string{"Seq3", "Seq4"} {
+		rec, err := r.Read()
+		if err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+		if rec.Name() != want {
+			t.Errorf("header=%q want %q", rec.Name(), want)
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("error %v, want io.EOF", err)
+	}
+
+	// Line numbers restart.
+	r.Reset(strings.NewReader("AAA\n"))
+	var ferr *FormatError
+	if _, err := r.Read(); !errors.As(err, &ferr) || ferr.Line != 1 {
+		t.Errorf("error %v, want format error at line 1", err)
+	}
+
+	// Records seen by a dedup reader are forgotten.
+	r = NewDedupReader(strings.NewReader(">Seq1\nAAA\n>Seq1\nCCC\n"), DedupHeader)
+	for {
+		if _, err := r.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+	}
+	r.Reset(strings.NewReader(">Seq1\nGGG\n"))
+	if rec, err := r.Read(); err != nil || string(rec.Seq()) != "GGG" {
+		t.Errorf("got %v, %v want Seq1 GGG", rec, err)
+	}
+}
+
+func TestNewSortedReader(t *testing.T) {
+	in := ">b\nAAAA\n>c\nA\n>a\nAA\n>d\nA\n"
+	var tests = []struct {
+		Test    string
+		Less    func(a, b *Record) bool
+		Headers []string
+	}{
+		{
+			Test:    "by header",
+			Less:    func(a, b *Record) bool { return a.Header < b.Header },
+			Headers: []string{"a", "b", "c", "d"},
+		},
+		{
+			Test:    "by length stable",
+			Less:    func(a, b *Record) bool { return len(a.Sequence) < len(b.Sequence) },
+			Headers: []string{"c", "d", "a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		r, err := NewSortedReader(strings.NewReader(in), tt.Less)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+
+		for _, h := range tt.Headers {
+			rec, err := r.Read()
+			if err != nil {
+				t.Fatalf("%s: unexpected error %q", tt.Test, err.Error())
+			}
+			if rec.Name() != h {
+				t.Errorf("%s: header=%q want %q", tt.Test, rec.Name(), h)
+			}
+		}
+		if rec, err := r.Read(); rec != nil || err != io.EOF {
+			t.Errorf("%s: got %v, %v want nil, io.EOF", tt.Test, rec, err)
+		}
+	}
+
+	_, err := NewSortedReader(strings.NewReader("AAA\n>a\n"), nil)
+	if err == nil || !strings.Contains(err.Error(), "sequence before header") {
+		t.Errorf("error %v, want format error", err)
+	}
+}
+
+func TestReadAlphabet(t *testing.T) {
+	var tests = []struct {
+		Test     string
+		Data     string
+		Alphabet []byte
+		Err      string
+	}{
+		{"none", ">Seq1\nAC@T\n", nil, ""},
+		{"dna", ">Seq1\nACGT\nNNRY-\n", DNA, ""},
+		{"dna lowercase", ">Seq1\nacgtn\nACgt\n", DNA, ""},
+		{"dna invalid", ">Seq1\nACGT\n>Seq2\nAC@T\n", DNA, `fasta: format error at line 4: invalid character '@' in "Seq2"`},
+		{"dna uracil", ">Seq1\nACGU\n", DNA, `fasta: format error at line 2: invalid character 'U' in "Seq1"`},
+		{"rna", ">Seq1\nACGU\n", RNA, ""},
+		{"protein", ">Seq1\nMKVLA*\n", Protein, ""},
+		{"custom", ">Seq1\nAC\n", []byte("a"), `fasta: format error at line 2: invalid character 'C' in "Seq1"`},
+	}
+
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(tt.Data))
+		r.SetAlphabet(tt.Alphabet)
+
+		var err error
+		for err == nil {
+			_, err = r.Read()
+		}
+
+		if tt.Err != "" {
+			if err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+		} else if err != io.EOF {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+	}
+}
+
+func TestReadWarnings(t *testing.T) {
+	var tests = []struct {
+		Test     string
+		Data     string
+		Warnings []string
+	}{
+		{
+			Test:     "clean",
+			Data:     ">Seq1\nAC-GT*\n\n>Seq2\nacgt.\n",
+			Warnings: nil,
+		},
+		{
+			Test: "empty sequence",
+			Data: ">Seq1\n\n>Seq2\nAAA\n>Seq3\n",
+			Warnings: []string{
+				`fasta: line 1: empty sequence for "Seq1"`,
+				`fasta: line 5: empty sequence for "Seq3"`,
+			},
+		},
+		{
+			Test: "unusual characters",
+			Data: ">Seq1\nAAA\nA1A\nA@A\n>Seq2\nA A\n",
+			Warnings: []string{
+				`fasta: line 3: unusual character '1' in sequence of "Seq1"`,
+				`fasta: line 6: unusual character ' ' in sequence of "Seq2"`,
+			},
+		},
+		{
+			Test: "long header",
+			Data: ">" + strings.Repeat("H", 1001) + "\nAAA\n",
+			Warnings: []string{
+				`fasta: line 1: header of 1001 bytes`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(tt.Data))
+		for {
+			_, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error %q", tt.Test, err.Error())
+			}
+		}
+
+		if got := r.Warnings(); !reflect.DeepEqual(got, tt.Warnings) {
+			t.Errorf("%s: warnings=%q want %q", tt.Test, got, tt.Warnings)
+		}
+	}
+}
+
+func TestReadMaxHeaderLen(t *testing.T) {
+	var tests = []struct {
+		Test    string
+		Data    string
+		Max     int
+		Err     string
+		Headers []string
+	}{
+		{"unlimited", ">Seq1 long\nAAA\n", 0, "", []string{"Seq1 long"}},
+		{"at limit", ">Seq1\nAAA\n>Seq2 \r\nCCC\n", 4, "", []string{"Seq1", "Seq2"}},
+		{"over limit", ">Seq1\nAAA\n\n>Seq22\nCCC\n", 4, "fasta: header exceeds 4 bytes at line 4", nil},
+		{"no newline", ">Seq1\nAAA\n>Seq22", 4, "fasta: header exceeds 4 bytes at line 3", nil},
+		{"beyond buffer", ">" + strings.Repeat("H", 10000), 5000, "fasta: header exceeds 5000 bytes at line 1", nil},
+		{"chunked header", ">ok\nTT\n>" + strings.Repeat("H", 10000) + "\nACGT\n", 5000, "fasta: header exceeds 5000 bytes at line 3", nil},
+		{"long sequence", ">Seq1\n" + strings.Repeat("A", 10000) + "\n", 4, "", []string{"Seq1"}},
+	}
+
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(tt.Data))
+		r.MaxHeaderLen = tt.Max
+
+		var (
+			headers []string
+			err     error
+		)
+		for {
+			var rec *Record
+			if rec, err = r.Read(); err != nil {
+				break
+			}
+			headers = append(headers, rec.Name())
+		}
+
+		if tt.Err != "" {
+			if err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			// The error is sticky so the rest of the record is not read.
+			if rec, err := r.Read(); rec != nil || err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: read after error got %v, %v want nil, %q", tt.Test, rec, err, tt.Err)
+			}
+		} else if err != io.EOF {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+		if !reflect.DeepEqual(headers, tt.Headers) {
+			t.Errorf("%s: headers=%q want %q", tt.Test, headers, tt.Headers)
+		}
+	}
+}
+
+func TestReadMaxLineLen(t *testing.T) {
+	var tests = []struct {
+		Test string
+		Data string
+		Size int
+		Max  int
+		Err  string
+	}{
+		{"unlimited", ">Seq1\n" + strings.Repeat("A", 10000) + "\n", 16, 0, ""},
+		{"at limit", ">S1\nAAAA \r\nAAA\n", 16, 4, ""},
+		{"over limit", ">S1\nAAAA\nAAAAA\n", 16, 4, "fasta: line exceeds max length of 4 bytes at line 3"},
+		{"first line", ">a\nAAAAA\n", 16, 4, "fasta: line exceeds max length of 4 bytes at line 2"},
+		{"header", ">Seq123\nAAAA\n", 16, 4, "fasta: line exceeds max length of 4 bytes at line 1"},
+		{"beyond buffer", ">Seq1\n" + strings.Repeat("A", 10000), 16, 100, "fasta: line exceeds max length of 100 bytes at line 2"},
+	}
+
+	for _, tt := range tests {
+		r := NewReaderSize(strings.NewReader(tt.Data), tt.Size)
+		r.MaxLineLen = tt.Max
+
+		var err error
+		for err == nil {
+			_, err = r.Read()
+		}
+
+		if tt.Err != "" {
+			if err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			// The error is sticky so the rest of the record is not read.
+			if rec, err := r.Read(); rec != nil || err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: read after error got %v, %v want nil, %q", tt.Test, rec, err, tt.Err)
+			}
+		} else if err != io.EOF {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
 	}
 }
 
@@ -116,9 +811,25 @@ var writeTests = []struct {
 			&Record{Header: "Seq1", Sequence: []byte("AAABBB")},
 			&Record{Header: "Seq2", Sequence: []byte("CCCDDD")},
 		},
-		Output: ">Seq1\nA\nA\nA\nB\nB\nB\n>Seq2\nC\nC\nC\nD\nD\nD\n",
+		Output: ">Seq1\nAAABBB\n>Seq2\nCCCDDD\n",
 		Width:  0,
 	},
+	{
+		Test: "negative-width write",
+		Records: []*Record{
+			&Record{Header: "Seq", Sequence: []byte("AAABBB")},
+		},
+		Output: ">Seq\nAAABBB\n",
+		Width:  -1,
+	},
+	{
+		Test: "1-width write",
+		Records: []*Record{
+			&Record{Header: "Seq1", Sequence: []byte("AAB")},
+		},
+		Output: ">Seq1\nA\nA\nB\n",
+		Width:  1,
+	},
 }
 
 func TestWrite(t *testing.T) {
@@ -141,6 +852,371 @@ func TestWrite(t *testing.T) {
 	}
 }
 
+// countingWriter counts the calls to Write.
+type countingWriter struct {
+	bytes.Buffer
+	calls int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.calls++
+	return w.Buffer.Write(p)
+}
+
+func TestWriteBatched(t *testing.T) {
+	var tests = []struct {
+		Test  string
+		Len   int
+		Calls int
+	}{
+		{"short", 100, 1},
+		{"long", 1 << 20, 17},
+	}
+
+	for _, tt := range tests {
+		seq := bytes.Repeat([]byte("ACGT"), tt.Len/4)
+		b := &countingWriter{}
+		n, err := NewWriter(b, 60).Write(&Record{Header: "Seq1", Sequence: seq})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+
+		var want strings.Builder
+		want.WriteString(">Seq1")
+		for i := 0; i < len(seq); i += 60 {
+			end := i + 60
+			if end > len(seq) {
+				end = len(seq)
+			}
+			want.WriteString("\n" + string(seq[i:end]))
+		}
+		want.WriteString("\n")
+
+		if b.String() != want.String() {
+			t.Errorf("%s: unexpected output", tt.Test)
+		}
+		if n != want.Len() {
+			t.Errorf("%s: n=%d want %d", tt.Test, n, want.Len())
+		}
+		if b.calls != tt.Calls {
+			t.Errorf("%s: calls=%d want %d", tt.Test, b.calls, tt.Calls)
+		}
+	}
+}
+
+func TestWriteInvalid(t *testing.T) {
+	var tests = []struct {
+		Test  string
+		Rec   *Record
+		Width int
+		Err   string
+	}{
+		{"clean", &Record{Header: "Seq1 desc", Sequence: []byte("ACGT")}, 2, ""},
+		{"header newline", &Record{Header: "Seq1\n>Seq2", Sequence: []byte("ACGT")}, 2, "fasta: invalid header: contains newline"},
+		{"header carriage return", &Record{Header: "Seq1\r", Sequence: []byte("ACGT")}, 2, "fasta: invalid header: contains newline"},
+		{"sequence newline", &Record{Header: "Seq1", Sequence: []byte("AC\nGT")}, 2, "fasta: invalid sequence: contains newline"},
+		{"sequence header", &Record{Header: "Seq1", Sequence: []byte("AC>GT")}, 3, ""},
+		{"sequence header at line start", &Record{Header: "Seq1", Sequence: []byte("ACG>T")}, 3, "fasta: invalid sequence: '>' at start of line"},
+		{"sequence header at start", &Record{Header: "Seq1", Sequence: []byte(">ACGT")}, 0, "fasta: invalid sequence: '>' at start of line"},
+		{"sequence comment at line start", &Record{Header: "a", Sequence: []byte("AC;GTT")}, 2, "fasta: invalid sequence: ';' at start of line"},
+		{"sequence comment at start", &Record{Header: "a", Sequence: []byte(";AC")}, 0, "fasta: invalid sequence: ';' at start of line"},
+		{"sequence comment mid line", &Record{Header: "a", Sequence: []byte("A;C")}, 2, ""},
+	}
+
+	for _, tt := range tests {
+		b := &bytes.Buffer{}
+		n, err := NewWriter(b, tt.Width).Write(tt.Rec)
+
+		if tt.Err != "" {
+			if err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			if n != 0 || b.Len() != 0 {
+				t.Errorf("%s: unexpected output %q", tt.Test, b.String())
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+	}
+}
+
+func TestWritePreserveWidth(t *testing.T) {
+	seq := strings.Repeat("ACGTACGTAC", 15)
+	in := "" +
+		">Seq1\n" + seq[:70] + "\n" + seq[70:140] + "\n" + seq[140:] + "\n" +
+		">Seq2\n" + seq[:10] + "\n" + seq[10:20] + "\n" +
+		">Seq3\n"
+
+	r := NewReader(strings.NewReader(in))
+	b := &bytes.Buffer{}
+	w := NewWriter(b, WidthPreserve)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+		if _, err = w.Write(rec); err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+	}
+	if b.String() != in {
+		t.Errorf("out=%q want %q", b.String(), in)
+	}
+
+	// Records without a known width use the default.
+	b.Reset()
+	if _, err := w.Write(&Record{Header: "Seq4", Sequence: []byte(seq[:61])}); err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	if want := ">Seq4\n" + seq[:60] + "\n" + seq[60:61] + "\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+}
+
+func TestWriteString(t *testing.T) {
+	b1, b2 := &bytes.Buffer{}, &bytes.Buffer{}
+	w1, w2 := NewWriter(b1, 2), NewWriter(b2, 2)
+	for _, rec := range writeTests[0].Records {
+		n1, err1 := w1.Write(rec)
+		n2, err2 := w2.WriteString(rec.Header, string(rec.Sequence))
+		if err1 != nil || err2 != nil {
+			t.Fatalf("unexpected errors %v, %v", err1, err2)
+		}
+		if n1 != n2 {
+			t.Errorf("n=%d want %d", n2, n1)
+		}
+	}
+	if b2.String() != b1.String() {
+		t.Errorf("out=%q want %q", b2.String(), b1.String())
+	}
+}
+
+func TestWriteNoTrailingNewline(t *testing.T) {
+	var tests = []struct {
+		Test   string
+		Sep    string
+		Output string
+	}{
+		{"no separator", "", ">Seq1\nAA\nAB\nBB\n>Seq2\nCC\nCD\nDD"},
+		{"separator", "//\n", ">Seq1\nAA\nAB\nBB\n//\n>Seq2\nCC\nCD\nDD\n//\n"},
+	}
+
+	for _, tt := range tests {
+		b := &bytes.Buffer{}
+		w := NewWriter(b, 2)
+		w.NoTrailingNewline = true
+		w.RecordSeparator = tt.Sep
+
+		var n int
+		for _, rec := range writeTests[0].Records {
+			_n, err := w.Write(rec)
+			if err != nil {
+				t.Fatalf("%s: unexpected error %q", tt.Test, err.Error())
+			}
+			n += _n
+		}
+		if b.String() != tt.Output {
+			t.Errorf("%s: out=%q want %q", tt.Test, b.String(), tt.Output)
+		}
+		if n != len(tt.Output) {
+			t.Errorf("%s: n=%d want %d", tt.Test, n, len(tt.Output))
+		}
+	}
+}
+
+func TestWriteRecordSeparator(t *testing.T) {
+	var tests = []struct {
+		Test   string
+		Sep    string
+		Err    string
+		Output string
+	}{
+		{"none", "", "", ">Seq1\nAAA\n>Seq2\nCCC\n"},
+		{"slashes", "//\n", "", ">Seq1\nAAA\n//\n>Seq2\nCCC\n//\n"},
+		{"blank line", "\n", "", ">Seq1\nAAA\n\n>Seq2\nCCC\n\n"},
+		{"header", ">sep\n", "fasta: invalid record separator", ""},
+		{"header 2nd line", "//\n>sep\n", "fasta: invalid record separator", ""},
+		{"indented header", "//\n \t>sep\n", "fasta: invalid record separator: line starts with '>'", ""},
+		{"no newline", "//", "fasta: invalid record separator: missing trailing newline", ""},
+	}
+
+	for _, tt := range tests {
+		b := &bytes.Buffer{}
+		w := NewWriter(b, 60)
+		w.RecordSeparator = tt.Sep
+
+		var err error
+		for _, rec := range []*Record{
+			&Record{Header: "Seq1", Sequence: []byte("AAA")},
+			&Record{Header: "Seq2", Sequence: []byte("CCC")},
+		} {
+			if _, err = w.Write(rec); err != nil {
+				break
+			}
+		}
+
+		if tt.Err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.Err) {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			if b.Len() != 0 {
+				t.Errorf("%s: unexpected output %q", tt.Test, b.String())
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		if out := b.String(); out != tt.Output {
+			t.Errorf("%s: out=%q want %q", tt.Test, out, tt.Output)
+		}
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewWriter(b, 60)
+	w.AppendChecksum = true
+	for _, rec := range []*Record{
+		&Record{Header: "Seq1 desc", Sequence: []byte("AAABBB")},
+		&Record{Header: "Seq2", Sequence: []byte("")},
+	} {
+		if _, err := w.Write(rec); err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+	}
+
+	want := "" +
+		">Seq1 desc SHA256:80ea94cdc6e8a55a68c457dfc11d1b52813b23fbdc71380fd7ed563c435a732a\n" +
+		"AAABBB\n" +
+		">Seq2 SHA256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n"
+	if out := b.String(); out != want {
+		t.Fatalf("out=%q want %q", out, want)
+	}
+
+	// Verified read strips the checksums.
+	r := NewReader(strings.NewReader(want))
+	r.VerifyChecksum = true
+	for _, h := range []string{"Seq1 desc", "Seq2"} {
+		rec, err := r.Read()
+		if err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+		if rec.Name() != h {
+			t.Errorf("header=%q want %q", rec.Name(), h)
+		}
+	}
+
+	// Unverified read keeps them.
+	r = NewReader(strings.NewReader(want))
+	if rec, _ := r.Read(); !strings.HasSuffix(rec.Name(), "c435a732a") {
+		t.Errorf("header=%q, want checksum suffix", rec.Name())
+	}
+
+	// Mismatch.
+	bad := strings.Replace(want, "AAABBB", "AAABBC", 1)
+	r = NewReader(strings.NewReader(bad))
+	r.VerifyChecksum = true
+	if _, err := r.Read(); err == nil || err.Error() != `fasta: checksum mismatch for "Seq1 desc"` {
+		t.Errorf("error %v, want checksum mismatch", err)
+	}
+	if rec, err := r.Read(); err != nil || rec.Name() != "Seq2" {
+		t.Errorf("got %v, %v want Seq2 record", rec, err)
+	}
+}
+
+func TestWriteLowercase(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewWriter(b, 4)
+	w.Lowercase = true
+	rec := &Record{Header: "Seq1 ABC", Sequence: []byte("AAcGTN-")}
+	if _, err := w.Write(rec); err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+
+	if out, want := b.String(), ">Seq1 ABC\naacg\ntn-\n"; out != want {
+		t.Errorf("out=%q want %q", out, want)
+	}
+	if string(rec.Seq()) != "AAcGTN-" {
+		t.Errorf("record modified to %q", string(rec.Seq()))
+	}
+}
+
+func TestWriteUniquifyHeaders(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewWriter(b, 60)
+	w.UniquifyHeaders = true
+	for _, h := range []string{"a", "b", "a", "a.1", "a", "b"} {
+		if _, err := w.Write(&Record{Header: h, Sequence: []byte("A")}); err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+	}
+
+	want := ">a\nA\n>b\nA\n>a.1\nA\n>a.1.1\nA\n>a.2\nA\n>b.1\nA\n"
+	if out := b.String(); out != want {
+		t.Errorf("out=%q want %q", out, want)
+	}
+
+	renames := map[string][]string{
+		"a":   []string{"a.1", "a.2"},
+		"a.1": []string{"a.1.1"},
+		"b":   []string{"b.1"},
+	}
+	if got := w.Renames(); !reflect.DeepEqual(got, renames) {
+		t.Errorf("renames=%v want %v", got, renames)
+	}
+}
+
+func TestGzip(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewWriterGzip(b, 2)
+	for _, rec := range writeTests[0].Records {
+		if _, err := w.Write(rec); err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	if string(out) != writeTests[0].Output {
+		t.Errorf("out=%q want %q", string(out), writeTests[0].Output)
+	}
+
+	r, err := NewReaderGzip(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	for _, want := range writeTests[0].Records {
+		rec, err := r.Read()
+		if err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+		if rec.Name() != want.Name() || string(rec.Seq()) != string(want.Seq()) {
+			t.Errorf("got %q %q want %q %q", rec.Name(), rec.Seq(), want.Name(), want.Seq())
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("error %v, want io.EOF", err)
+	}
+
+	if _, err := NewReaderGzip(strings.NewReader(">Seq1\nAAA\n")); err == nil {
+		t.Errorf("expected error for uncompressed input")
+	}
+}
+
 func ExampleReader() {
 	in := ">Seq1\nAAA\nBBB\n"
 	r := NewReader(strings.NewReader(in))
@@ -162,6 +1238,87 @@ func ExampleReader() {
 	// AAABBB
 }
 
+func TestReadContext(t *testing.T) {
+	r := NewReader(strings.NewReader(">Seq1\nAAA\n>Seq2\nCCC\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec, err := r.ReadContext(ctx)
+	if err != nil || rec.Name() != "Seq1" {
+		t.Fatalf("got %v, %v want Seq1", rec, err)
+	}
+
+	cancel()
+	if rec, err := r.ReadContext(ctx); rec != nil || err != context.Canceled {
+		t.Errorf("got %v, %v want nil, context.Canceled", rec, err)
+	}
+
+	// Resume with a live context.
+	rec, err = r.ReadContext(context.Background())
+	if err != nil || rec.Name() != "Seq2" || string(rec.Seq()) != "CCC" {
+		t.Errorf("got %v, %v want Seq2", rec, err)
+	}
+}
+
+func TestReaderAll(t *testing.T) {
+	r := NewReader(strings.NewReader(">Seq1\nAAA\n>Seq2\nCCC\n>Seq3\nGGG\n"))
+
+	// Break early.
+	for rec, err := range r.All() {
+		if err != nil || rec.Name() != "Seq1" {
+			t.Fatalf("got %v, %v want Seq1", rec, err)
+		}
+		break
+	}
+
+	// Resume with the remaining records.
+	var headers []string
+	for rec, err := range r.All() {
+		if err != nil {
+			t.Fatalf("unexpected error %q", err.Error())
+		}
+		headers = append(headers, rec.Name())
+	}
+	if want := []string{"Seq2", "Seq3"}; !reflect.DeepEqual(headers, want) {
+		t.Errorf("headers=%q want %q", headers, want)
+	}
+
+	// Exhausted.
+	for rec, err := range r.All() {
+		t.Errorf("unexpected yield %v, %v", rec, err)
+	}
+
+	// Error.
+	r = NewReader(strings.NewReader("AAA\n>Seq1\n"))
+	var n int
+	for rec, err := range r.All() {
+		if n++; rec != nil || err == nil {
+			t.Errorf("got %v, %v want nil, error", rec, err)
+		}
+	}
+	if n != 1 {
+		t.Errorf("yields=%d want 1", n)
+	}
+}
+
+func ExampleReader_All() {
+	in := ">Seq1\nAAA\n>Seq2\nBBB\n"
+	r := NewReader(strings.NewReader(in))
+
+	for record, err := range r.All() {
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%s\n", record.Name())
+		fmt.Printf("%s\n", record.Seq())
+	}
+	// Output:
+	// Seq1
+	// AAA
+	// Seq2
+	// BBB
+}
+
 func ExampleWriter() {
 	b := &bytes.Buffer{}
 	r := &Record{
@@ -182,3 +1339,52 @@ func ExampleWriter() {
 	// AAAB
 	// BB
 }
+
+func TestErrorsIs(t *testing.T) {
+	var tests = []struct {
+		Test      string
+		Data      string
+		MaxLine   int
+		MaxHeader int
+		Err       error
+	}{
+		{"no header", "ACGT\n>a\nACGT\n", 0, 0, ErrNoHeader},
+		{"invalid char", ">a\nACXT\n", 0, 0, ErrInvalidChar},
+		{"long line", ">a\nACGTACGTACGT\n", 10, 0, ErrLineTooLong},
+		{"long header", ">abcdefgh\nACGT\n", 0, 5, ErrHeaderTooLong},
+		{"checksum", ">a SHA256:" + strings.Repeat("0", 64) + "\nACGT\n", 0, 0, ErrChecksum},
+	}
+
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(tt.Data))
+		r.SetAlphabet(DNA)
+		r.MaxLineLen = tt.MaxLine
+		r.MaxHeaderLen = tt.MaxHeader
+		r.VerifyChecksum = true
+		var err error
+		for err == nil {
+			_, err = r.Read()
+		}
+		if !errors.Is(err, tt.Err) {
+			t.Errorf("%s: error %v, want errors.Is %q", tt.Test, err, tt.Err)
+		}
+	}
+
+	var fe *FormatError
+	_, err := NewReader(strings.NewReader("ACGT\n")).Read()
+	if !errors.As(err, &fe) || fe.Line != 1 {
+		t.Errorf("error %v, want *FormatError at line 1", err)
+	}
+
+	w := NewWriter(&bytes.Buffer{}, 60)
+	if _, err := w.Write(&Record{Header: "a\nb", Sequence: []byte("A")}); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("error %v, want errors.Is %q", err, ErrInvalidHeader)
+	}
+	if _, err := w.Write(&Record{Header: "a", Sequence: []byte("A\n>")}); !errors.Is(err, ErrInvalidSequence) {
+		t.Errorf("error %v, want errors.Is %q", err, ErrInvalidSequence)
+	}
+	w.RecordSeparator = ">"
+	if _, err := w.Write(&Record{Header: "a", Sequence: []byte("A")}); !errors.Is(err, ErrInvalidSeparator) {
+		t.Errorf("error %v, want errors.Is %q", err, ErrInvalidSeparator)
+	}
+}
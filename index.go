@@ -0,0 +1,189 @@
+package fasta
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// IndexEntry is the entry of a sequence in a faidx compatible (.fai) index.
+type IndexEntry struct {
+	Name      string // sequence name, i.e. the header up to the first whitespace.
+	Length    int64  // number of bases.
+	Offset    int64  // byte offset of the first base.
+	LineBases int64  // number of bases in each line.
+	LineWidth int64  // number of bytes in each line, including the newline.
+}
+
+// BuildIndex returns the faidx index of the FASTA file r. As required by the
+// format, all sequence lines of a record but the last must have the same
+// length and the last must not be longer; an error is returned otherwise.
+func BuildIndex(r io.ReadSeeker) ([]IndexEntry, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var (
+		br     = bufio.NewReader(r)
+		idx    []IndexEntry
+		e      *IndexEntry
+		offset int64 // of the current line.
+		lineNo int
+		short  bool // whether the current record had a shorter line.
+	)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(line) == 0 {
+			break
+		}
+		lineNo++
+		width := int64(len(line))
+		bases := int64(len(bytes.TrimRight(line, "\r\n")))
+
+		switch {
+		case line[0] == '>':
+			if e != nil {
+				idx = append(idx, *e)
+			}
+			name, _ := splitHeader(string(bytes.TrimSpace(line[1:])))
+			e = &IndexEntry{Name: name, Offset: offset + width}
+			short = false
+		case e == nil:
+			if len(bytes.TrimSpace(line)) > 0 {
+				return nil, fmt.Errorf("fasta: index: format error at line %d: sequence before header", lineNo)
+			}
+			// Skip blank lines before the first header.
+		case e.LineBases == 0:
+			if bases == 0 { // Skip blank lines before the sequence.
+				e.Offset = offset + width
+				break
+			}
+			e.Offset, e.LineBases, e.LineWidth = offset, bases, width
+			e.Length = bases
+		default:
+			newline := width - bases
+			if bases > 0 && (short || bases > e.LineBases || err != io.EOF && newline != e.LineWidth-e.LineBases) {
+				return nil, fmt.Errorf("fasta: index: inconsistent line length in %q at line %d", e.Name, lineNo)
+			}
+			if bases < e.LineBases {
+				short = true
+			}
+			e.Length += bases
+		}
+
+		offset += width
+		if err == io.EOF {
+			break
+		}
+	}
+	if e != nil {
+		idx = append(idx, *e)
+	}
+	return idx, nil
+}
+
+// WriteIndex writes idx to w in the tab-separated faidx (.fai) format.
+func WriteIndex(w io.Writer, idx []IndexEntry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range idx {
+		_, err := fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", e.Name, e.Length, e.Offset, e.LineBases, e.LineWidth)
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadIndex reads a faidx (.fai) index from r.
+func ReadIndex(r io.Reader) ([]IndexEntry, error) {
+	var (
+		idx    []IndexEntry
+		s      = bufio.NewScanner(r)
+		lineNo int
+	)
+	for s.Scan() {
+		lineNo++
+		fields := strings.Split(s.Text(), "\t")
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("fasta: index: format error at line %d: want 5 fields, got %d", lineNo, len(fields))
+		}
+		var nums [4]int64
+		for i := range nums {
+			n, err := strconv.ParseInt(fields[i+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("fasta: index: format error at line %d: %v", lineNo, err)
+			}
+			nums[i] = n
+		}
+		idx = append(idx, IndexEntry{
+			Name:      fields[0],
+			Length:    nums[0],
+			Offset:    nums[1],
+			LineBases: nums[2],
+			LineWidth: nums[3],
+		})
+	}
+	return idx, s.Err()
+}
+
+// An IndexedReader extracts regions of the sequences of a FASTA file using
+// its faidx index, seeking directly to them without reading the rest of the
+// file.
+type IndexedReader struct {
+	r       io.ReadSeeker
+	entries map[string]IndexEntry
+}
+
+// NewIndexedReader returns a new reader for the FASTA file r indexed by idx.
+func NewIndexedReader(r io.ReadSeeker, idx []IndexEntry) *IndexedReader {
+	entries := make(map[string]IndexEntry, len(idx))
+	for _, e := range idx {
+		entries[e.Name] = e
+	}
+	return &IndexedReader{r: r, entries: entries}
+}
+
+// Subseq returns the bases of sequence name in the 0-based half-open range
+// [start, end).
+func (ir *IndexedReader) Subseq(name string, start, end int) ([]byte, error) {
+	e, ok := ir.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("fasta: index: unknown sequence %q", name)
+	}
+	if start < 0 || int64(end) > e.Length || start > end {
+		return nil, fmt.Errorf("fasta: index: invalid range %d-%d for %q of length %d", start, end, name, e.Length)
+	}
+	if start == end {
+		return []byte{}, nil
+	}
+	if e.LineBases <= 0 {
+		return nil, errors.New("fasta: index: invalid line bases")
+	}
+
+	pos := func(i int64) int64 {
+		return e.Offset + i/e.LineBases*e.LineWidth + i%e.LineBases
+	}
+	from, to := pos(int64(start)), pos(int64(end-1))+1
+	if _, err := ir.r.Seek(from, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, to-from)
+	if _, err := io.ReadFull(ir.r, buf); err != nil {
+		return nil, err
+	}
+
+	seq := buf[:0]
+	for _, c := range buf {
+		if c != '\n' && c != '\r' {
+			seq = append(seq, c)
+		}
+	}
+	return seq, nil
+}
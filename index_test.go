@@ -0,0 +1,169 @@
+package fasta
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test BuildIndex
+var indexTests = []struct {
+	Test  string
+	Data  string
+	Err   string
+	Index []IndexEntry
+}{
+	{
+		Test:  "empty",
+		Data:  "",
+		Index: nil,
+	},
+	{
+		Test: "2-seq",
+		Data: "" +
+			">Seq1 desc\n" +
+			"AAAA\n" +
+			"CCCC\n" +
+			"GG\n" +
+			">Seq2\n" +
+			"TTT\n",
+		Index: []IndexEntry{
+			{Name: "Seq1", Length: 10, Offset: 11, LineBases: 4, LineWidth: 5},
+			{Name: "Seq2", Length: 3, Offset: 30, LineBases: 3, LineWidth: 4},
+		},
+	},
+	{
+		Test: "crlf no newline",
+		Data: "" +
+			">Seq1\r\n" +
+			"AAAA\r\n" +
+			"CC",
+		Index: []IndexEntry{
+			{Name: "Seq1", Length: 6, Offset: 7, LineBases: 4, LineWidth: 6},
+		},
+	},
+	{
+		Test: "blank lines",
+		Data: "" +
+			">Seq1\n" +
+			"\n" +
+			"AAAA\n" +
+			"CC\n" +
+			"\n" +
+			">Seq2\n",
+		Index: []IndexEntry{
+			{Name: "Seq1", Length: 6, Offset: 7, LineBases: 4, LineWidth: 5},
+			{Name: "Seq2", Length: 0, Offset: 22, LineBases: 0, LineWidth: 0},
+		},
+	},
+	{
+		Test: "longer line",
+		Data: ">Seq1\nAAAA\nCCCCC\nGG\n",
+		Err:  `fasta: index: inconsistent line length in "Seq1" at line 3`,
+	},
+	{
+		Test: "shorter line",
+		Data: ">Seq1\nAAAA\nCC\nGGGG\n",
+		Err:  `fasta: index: inconsistent line length in "Seq1" at line 4`,
+	},
+	{
+		Test: "inner blank line",
+		Data: ">Seq1\nAAAA\n\nGGGG\n",
+		Err:  `fasta: index: inconsistent line length in "Seq1" at line 4`,
+	},
+	{
+		Test: "format error",
+		Data: "AAA\n>Seq1\n",
+		Err:  "fasta: index: format error at line 1: sequence before header",
+	},
+}
+
+func TestBuildIndex(t *testing.T) {
+	for _, tt := range indexTests {
+		idx, err := BuildIndex(strings.NewReader(tt.Data))
+
+		if tt.Err != "" {
+			if err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		if !reflect.DeepEqual(idx, tt.Index) {
+			t.Errorf("%s: index=%v want %v", tt.Test, idx, tt.Index)
+		}
+	}
+}
+
+func TestWriteReadIndex(t *testing.T) {
+	idx := indexTests[1].Index
+	b := &bytes.Buffer{}
+	if err := WriteIndex(b, idx); err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+
+	want := "Seq1\t10\t11\t4\t5\nSeq2\t3\t30\t3\t4\n"
+	if b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+
+	got, err := ReadIndex(b)
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	if !reflect.DeepEqual(got, idx) {
+		t.Errorf("index=%v want %v", got, idx)
+	}
+
+	if _, err = ReadIndex(strings.NewReader("Seq1\t10\n")); err == nil {
+		t.Errorf("expected error for truncated entry")
+	}
+}
+
+// Test Subseq
+var indexedSubseqTests = []struct {
+	Test       string
+	Name       string
+	Start, End int
+	Err        string
+	Seq        string
+}{
+	{Test: "whole", Name: "Seq1", Start: 0, End: 10, Seq: "AAACCCCGGT"},
+	{Test: "across lines", Name: "Seq1", Start: 2, End: 8, Seq: "ACCCCG"},
+	{Test: "line boundary", Name: "Seq1", Start: 4, End: 5, Seq: "C"},
+	{Test: "last", Name: "Seq2", Start: 2, End: 3, Seq: "a"},
+	{Test: "empty", Name: "Seq2", Start: 1, End: 1, Seq: ""},
+	{Test: "out of range", Name: "Seq1", Start: 5, End: 11, Err: `fasta: index: invalid range 5-11 for "Seq1" of length 10`},
+	{Test: "unknown", Name: "Seq3", Start: 0, End: 1, Err: `fasta: index: unknown sequence "Seq3"`},
+}
+
+func TestIndexedReaderSubseq(t *testing.T) {
+	data := ">Seq1 desc\r\nAAAC\r\nCCCG\r\nGT\r\n>Seq2\nTTa\n"
+	idx, err := BuildIndex(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	ir := NewIndexedReader(strings.NewReader(data), idx)
+
+	for _, tt := range indexedSubseqTests {
+		seq, err := ir.Subseq(tt.Name, tt.Start, tt.End)
+
+		if tt.Err != "" {
+			if err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		if string(seq) != tt.Seq {
+			t.Errorf("%s: seq=%q want %q", tt.Test, string(seq), tt.Seq)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package fasta
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A PairReader reads paired records, such as the two reads of paired-end
+// sequencing, from two inputs in lockstep.
+type PairReader struct {
+	r1, r2 *Reader
+}
+
+// NewPairReader returns a new reader that reads the first records of each pair
+// from f1 and the second from f2.
+func NewPairReader(f1, f2 io.Reader) *PairReader {
+	return &PairReader{r1: NewReader(f1), r2: NewReader(f2)}
+}
+
+// ReadPair returns the next record of each input. It returns io.EOF when both
+// inputs end together and an error if only one does. It also returns an error
+// if the IDs of the records differ after removing any "/1" and "/2" suffix.
+func (pr *PairReader) ReadPair() (*Record, *Record, error) {
+	rec1, err1 := pr.r1.Read()
+	if err1 != nil && err1 != io.EOF {
+		return nil, nil, err1
+	}
+	rec2, err2 := pr.r2.Read()
+	if err2 != nil && err2 != io.EOF {
+		return nil, nil, err2
+	}
+
+	switch {
+	case err1 == io.EOF && err2 == io.EOF:
+		return nil, nil, io.EOF
+	case err1 == io.EOF:
+		return nil, nil, errors.New("fasta: pair: first input has fewer records than second")
+	case err2 == io.EOF:
+		return nil, nil, errors.New("fasta: pair: second input has fewer records than first")
+	}
+
+	id1, id2 := strings.TrimSuffix(rec1.ID(), "/1"), strings.TrimSuffix(rec2.ID(), "/2")
+	if id1 != id2 {
+		return nil, nil, fmt.Errorf("fasta: pair: mismatched IDs %q and %q", rec1.ID(), rec2.ID())
+	}
+	return rec1, rec2, nil
+}
@@ -0,0 +1,82 @@
+package fasta
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// Test ReadPair
+var pairTests = []struct {
+	Test  string
+	Data1 string
+	Data2 string
+	Err   string
+	Pairs int
+}{
+	{
+		Test:  "matched",
+		Data1: ">r1/1 lane1\nAAA\n>r2/1\nCCC\n>r3\nGGG\n",
+		Data2: ">r1/2 lane1\nTTT\n>r2/2\nGGG\n>r3\nCCC\n",
+		Pairs: 3,
+	},
+	{
+		Test:  "first shorter",
+		Data1: ">r1/1\nAAA\n",
+		Data2: ">r1/2\nTTT\n>r2/2\nGGG\n",
+		Err:   "fasta: pair: first input has fewer records than second",
+		Pairs: 1,
+	},
+	{
+		Test:  "second shorter",
+		Data1: ">r1/1\nAAA\n>r2/1\nCCC\n",
+		Data2: ">r1/2\nTTT\n",
+		Err:   "fasta: pair: second input has fewer records than first",
+		Pairs: 1,
+	},
+	{
+		Test:  "mismatched ids",
+		Data1: ">r1/1\nAAA\n>r2/1\nCCC\n",
+		Data2: ">r1/2\nTTT\n>r3/2\nGGG\n",
+		Err:   `fasta: pair: mismatched IDs "r2/1" and "r3/2"`,
+		Pairs: 1,
+	},
+	{
+		Test:  "swapped suffixes",
+		Data1: ">r1/2\nAAA\n",
+		Data2: ">r1/1\nTTT\n",
+		Err:   `fasta: pair: mismatched IDs "r1/2" and "r1/1"`,
+	},
+}
+
+func TestPairReader(t *testing.T) {
+	for _, tt := range pairTests {
+		pr := NewPairReader(strings.NewReader(tt.Data1), strings.NewReader(tt.Data2))
+
+		var (
+			n   int
+			err error
+		)
+		for {
+			var rec1, rec2 *Record
+			if rec1, rec2, err = pr.ReadPair(); err != nil {
+				break
+			}
+			if rec1 == nil || rec2 == nil {
+				t.Fatalf("%s: unexpected nil record", tt.Test)
+			}
+			n++
+		}
+
+		if tt.Err != "" {
+			if err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+		} else if err != io.EOF {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+		if n != tt.Pairs {
+			t.Errorf("%s: pairs=%d want %d", tt.Test, n, tt.Pairs)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package fasta
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// PipeThrough streams the records of in through the standard input of the
+// external command cmd and writes the records the command emits on its
+// standard output to out. Records are sent to the command wrapped at the
+// width of out. It returns the number of records written to out and any
+// error.
+//
+// The command is assumed to emit exactly one record for each input record and
+// in input order; an error is returned if the record counts differ. Input is
+// fed concurrently with reading the output so commands that write before
+// consuming all of their input do not deadlock.
+func PipeThrough(in *Reader, out *Writer, cmd string, args ...string) (int, error) {
+	c := exec.Command(cmd, args...)
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		return 0, err
+	}
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	if err = c.Start(); err != nil {
+		return 0, err
+	}
+
+	// Feed the command; report the number of records sent.
+	type result struct {
+		n   int
+		err error
+	}
+	sent := make(chan result, 1)
+	go func() {
+		var (
+			n   int
+			err error
+		)
+		w := NewWriter(stdin, out.width)
+		for {
+			var rec *Record
+			rec, err = in.Read()
+			if err != nil {
+				break
+			}
+			if _, err = w.Write(rec); err != nil {
+				break
+			}
+			n++
+		}
+		if err == io.EOF {
+			err = nil
+		}
+		if cerr := stdin.Close(); err == nil {
+			err = cerr
+		}
+		sent <- result{n, err}
+	}()
+
+	// Collect the output of the command.
+	var n int
+	r := NewReader(stdout)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			_, err = out.Write(rec)
+		}
+		if err != nil {
+			c.Process.Kill()
+			io.Copy(io.Discard, stdout)
+			<-sent
+			c.Wait()
+			return n, err
+		}
+		n++
+	}
+
+	res := <-sent
+	if err = c.Wait(); err != nil {
+		return n, err
+	}
+	if res.err != nil {
+		return n, res.err
+	}
+	if res.n != n {
+		return n, fmt.Errorf("fasta: pipe: %s returned %d records for %d input records", cmd, n, res.n)
+	}
+	return n, nil
+}
@@ -0,0 +1,66 @@
+package fasta
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// Test PipeThrough
+var pipeTests = []struct {
+	Test   string
+	Cmd    []string
+	Input  string
+	Output string
+	Err    string
+	Count  int
+}{
+	{
+		Test:   "cat",
+		Cmd:    []string{"cat"},
+		Input:  ">Seq1\nAAABBB\n>Seq2\nCCC\n",
+		Output: ">Seq1\nAAAB\nBB\n>Seq2\nCCC\n",
+		Count:  2,
+	},
+	{
+		Test:   "transform",
+		Cmd:    []string{"tr", "a-z", "A-Z"},
+		Input:  ">Seq1\naaabbb\n>Seq2\nccc\n",
+		Output: ">SEQ1\nAAAB\nBB\n>SEQ2\nCCC\n",
+		Count:  2,
+	},
+	{
+		Test:  "count mismatch",
+		Cmd:   []string{"sed", "3,$d"},
+		Input: ">Seq1\nAAABBB\n>Seq2\nCCC\n",
+		Err:   "fasta: pipe: sed returned 1 records for 2 input records",
+		Count: 1,
+	},
+}
+
+func TestPipeThrough(t *testing.T) {
+	for _, tt := range pipeTests {
+		if _, err := exec.LookPath(tt.Cmd[0]); err != nil {
+			t.Skipf("%s: %s not available", tt.Test, tt.Cmd[0])
+		}
+
+		b := &bytes.Buffer{}
+		n, err := PipeThrough(NewReader(strings.NewReader(tt.Input)), NewWriter(b, 4), tt.Cmd[0], tt.Cmd[1:]...)
+
+		if tt.Err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.Err) {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		} else if out := b.String(); out != tt.Output {
+			t.Errorf("%s: out=%q want %q", tt.Test, out, tt.Output)
+		}
+
+		if n != tt.Count {
+			t.Errorf("%s: n=%d want %d", tt.Test, n, tt.Count)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package fasta
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// A QualReader reads the base qualities of a .qual file, which holds one
+// record per sequence of a parallel FASTA file with the qualities written as
+// whitespace separated integers, possibly wrapped over multiple lines.
+type QualReader struct {
+	r      *bufio.Reader
+	line   int
+	header string
+	quals  []uint8
+	inRec  bool
+	err    error
+}
+
+// NewQualReader returns a new reader that reads qualities from f.
+func NewQualReader(f io.Reader) *QualReader {
+	return &QualReader{r: bufio.NewReader(f)}
+}
+
+// Read returns the header and qualities of the next record. It returns
+// io.EOF when no records are left.
+func (qr *QualReader) Read() (string, []uint8, error) {
+	if qr.err != nil {
+		return "", nil, qr.err
+	}
+	for {
+		line, err := qr.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			qr.err = err
+			return "", nil, err
+		}
+		if len(line) > 0 {
+			qr.line++
+		}
+		line = bytes.TrimSpace(line)
+		switch {
+		case len(line) > 0 && line[0] == '>':
+			header, quals, ok := qr.header, qr.quals, qr.inRec
+			qr.header, qr.quals, qr.inRec = string(line[1:]), []uint8{}, true
+			if ok {
+				return header, quals, nil
+			}
+		case len(line) > 0:
+			if !qr.inRec {
+				qr.err = &FormatError{Line: qr.line, Msg: "sequence before header", Err: ErrNoHeader}
+				return "", nil, qr.err
+			}
+			for _, f := range bytes.Fields(line) {
+				q, perr := strconv.ParseUint(string(f), 10, 8)
+				if perr != nil {
+					qr.err = &FormatError{Line: qr.line, Msg: fmt.Sprintf("invalid quality %q", f)}
+					return "", nil, qr.err
+				}
+				qr.quals = append(qr.quals, uint8(q))
+			}
+		}
+		if err == io.EOF {
+			qr.err = io.EOF
+			if qr.inRec {
+				qr.inRec = false
+				return qr.header, qr.quals, nil
+			}
+			return "", nil, io.EOF
+		}
+	}
+}
+
+// ReadWithQual reads all records of seqs together with their qualities from
+// quals. It returns an error if the headers of the two inputs differ, if one
+// has more records than the other or if the number of qualities of a record
+// differs from its sequence length.
+func ReadWithQual(seqs, quals io.Reader) ([]*Record, [][]uint8, error) {
+	r, qr := NewReader(seqs), NewQualReader(quals)
+	recs, qs := []*Record{}, [][]uint8{}
+	for {
+		rec, err := r.Read()
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		header, q, qerr := qr.Read()
+		if qerr != nil && qerr != io.EOF {
+			return nil, nil, qerr
+		}
+
+		switch {
+		case err == io.EOF && qerr == io.EOF:
+			return recs, qs, nil
+		case err == io.EOF:
+			return nil, nil, fmt.Errorf("fasta: qual: no sequence for qualities %q", header)
+		case qerr == io.EOF:
+			return nil, nil, fmt.Errorf("fasta: qual: no qualities for %q", rec.Header)
+		case header != rec.Header:
+			return nil, nil, fmt.Errorf("fasta: qual: mismatched headers %q and %q", rec.Header, header)
+		case len(q) != len(rec.Sequence):
+			return nil, nil, fmt.Errorf("fasta: qual: %d qualities for sequence %q of length %d", len(q), rec.Header, len(rec.Sequence))
+		}
+		recs, qs = append(recs, rec), append(qs, q)
+	}
+}
@@ -0,0 +1,85 @@
+package fasta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test ReadWithQual
+var qualTests = []struct {
+	Test  string
+	Seqs  string
+	Quals string
+	Err   string
+	Want  [][]uint8
+}{
+	{
+		Test:  "aligned",
+		Seqs:  ">r1 lane1\nACGTA\nCG\n>r2\nTT\n",
+		Quals: ">r1 lane1\n40 40 38\n  20 10\n\n30 0\n>r2\n255 7\n",
+		Want:  [][]uint8{{40, 40, 38, 20, 10, 30, 0}, {255, 7}},
+	},
+	{
+		Test: "empty",
+		Want: [][]uint8{},
+	},
+	{
+		Test:  "length mismatch",
+		Seqs:  ">r1\nACGT\n",
+		Quals: ">r1\n40 40\n40\n",
+		Err:   `fasta: qual: 3 qualities for sequence "r1" of length 4`,
+	},
+	{
+		Test:  "mismatched headers",
+		Seqs:  ">r1\nAC\n>r2\nAC\n",
+		Quals: ">r1\n1 2\n>r3\n1 2\n",
+		Err:   `fasta: qual: mismatched headers "r2" and "r3"`,
+	},
+	{
+		Test:  "missing qualities",
+		Seqs:  ">r1\nAC\n>r2\nAC\n",
+		Quals: ">r1\n1 2\n",
+		Err:   `fasta: qual: no qualities for "r2"`,
+	},
+	{
+		Test:  "missing sequence",
+		Seqs:  ">r1\nAC\n",
+		Quals: ">r1\n1 2\n>r2\n3\n",
+		Err:   `fasta: qual: no sequence for qualities "r2"`,
+	},
+	{
+		Test:  "invalid quality",
+		Seqs:  ">r1\nAC\n",
+		Quals: ">r1\n1 256\n",
+		Err:   `fasta: format error at line 2: invalid quality "256"`,
+	},
+	{
+		Test:  "quality before header",
+		Seqs:  ">r1\nAC\n",
+		Quals: "1 2\n>r1\n1 2\n",
+		Err:   "fasta: format error at line 1: sequence before header",
+	},
+}
+
+func TestReadWithQual(t *testing.T) {
+	for _, tt := range qualTests {
+		recs, quals, err := ReadWithQual(strings.NewReader(tt.Seqs), strings.NewReader(tt.Quals))
+		if tt.Err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.Err) {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(quals, tt.Want) {
+			t.Errorf("%s: quals=%v want %v", tt.Test, quals, tt.Want)
+		}
+		if len(recs) != len(quals) {
+			t.Errorf("%s: %d records for %d quality arrays", tt.Test, len(recs), len(quals))
+		}
+	}
+}
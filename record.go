@@ -0,0 +1,317 @@
+package fasta
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// complement maps each IUPAC nucleotide code to its complement. Bytes that
+// are not nucleotide codes map to zero.
+var complement [256]byte
+
+func init() {
+	pairs := []string{"AT", "CG", "UA", "RY", "KM", "SS", "WW", "BV", "DH", "NN", "--"}
+	for _, p := range pairs {
+		a, b := p[0], p[1]
+		complement[a], complement[a|0x20] = b, b|0x20
+		if complement[b] == 0 {
+			complement[b], complement[b|0x20] = a, a|0x20
+		}
+	}
+}
+
+// Length returns the length of the sequence.
+func (rec *Record) Length() int {
+	return len(rec.Seq())
+}
+
+// BaseCounts returns the number of occurrences of each distinct byte of the
+// sequence. Letters are folded to upper case.
+func (rec *Record) BaseCounts() map[byte]int {
+	counts := make(map[byte]int)
+	for _, c := range rec.Seq() {
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		counts[c]++
+	}
+	return counts
+}
+
+// GCContent returns the fraction of G and C among the A, C, G and T bases of
+// the sequence, ignoring case. Other bytes, such as N or gaps, are not
+// counted. It returns 0 if the sequence has none of these bases.
+func (rec *Record) GCContent() float64 {
+	var gc, total int
+	for _, c := range rec.Seq() {
+		switch c {
+		case 'G', 'C', 'g', 'c':
+			gc++
+			total++
+		case 'A', 'T', 'a', 't':
+			total++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(gc) / float64(total)
+}
+
+// ToUpper returns a new record with the letters of the sequence in upper
+// case. The header is carried over.
+func (rec *Record) ToUpper() *Record {
+	seq := append([]byte{}, rec.Sequence...)
+	for i, c := range seq {
+		if 'a' <= c && c <= 'z' {
+			seq[i] = c - 'a' + 'A'
+		}
+	}
+	return &Record{Header: rec.Header, Sequence: seq}
+}
+
+// ToLower returns a new record with the letters of the sequence in lower
+// case. The header is carried over.
+func (rec *Record) ToLower() *Record {
+	seq := append([]byte{}, rec.Sequence...)
+	toLower(seq)
+	return &Record{Header: rec.Header, Sequence: seq}
+}
+
+// HardMask returns a new record with each lower case, i.e. soft-masked, letter
+// of the sequence replaced by N. The header is carried over.
+func (rec *Record) HardMask() *Record {
+	seq := append([]byte{}, rec.Sequence...)
+	for i, c := range seq {
+		if 'a' <= c && c <= 'z' {
+			seq[i] = 'N'
+		}
+	}
+	return &Record{Header: rec.Header, Sequence: seq}
+}
+
+// Complement returns a new record with each base of the sequence replaced by
+// its IUPAC complement without reversing the order. Case is preserved and
+// bytes that are not nucleotide codes are copied unchanged. The header is
+// carried over.
+func (rec *Record) Complement() *Record {
+	seq := make([]byte, len(rec.Sequence))
+	for i, c := range rec.Sequence {
+		if comp := complement[c]; comp != 0 {
+			c = comp
+		}
+		seq[i] = c
+	}
+	return &Record{Header: rec.Header, Sequence: seq}
+}
+
+// Reverse returns a new record with the sequence in reverse order. The header
+// is carried over.
+func (rec *Record) Reverse() *Record {
+	n := len(rec.Sequence)
+	seq := make([]byte, n)
+	for i, c := range rec.Sequence {
+		seq[n-1-i] = c
+	}
+	return &Record{Header: rec.Header, Sequence: seq}
+}
+
+// ReverseComplement returns a new record with the sequence reversed and each
+// base replaced by its IUPAC complement. Case is preserved. It returns an
+// error if the sequence contains a byte that is not a nucleotide code. The
+// header is carried over.
+func (rec *Record) ReverseComplement() (*Record, error) {
+	n := len(rec.Sequence)
+	seq := make([]byte, n)
+	for i, c := range rec.Sequence {
+		comp := complement[c]
+		if comp == 0 {
+			return nil, fmt.Errorf("fasta: invalid nucleotide %q at position %d", c, i)
+		}
+		seq[n-1-i] = comp
+	}
+	return &Record{Header: rec.Header, Sequence: seq}, nil
+}
+
+// CircularOverlap returns the length of the longest suffix of the sequence,
+// up to maxOverlap bytes, that is identical to its prefix. A non-zero value
+// indicates the ends of a circular sequence overlap and the suffix of that
+// length can be trimmed. It returns 0 if there is no overlap. The overlap is
+// always shorter than the sequence.
+func (rec *Record) CircularOverlap(maxOverlap int) int {
+	seq := rec.Sequence
+	if maxOverlap > len(seq)-1 {
+		maxOverlap = len(seq) - 1
+	}
+	for k := maxOverlap; k > 0; k-- {
+		if bytes.Equal(seq[:k], seq[len(seq)-k:]) {
+			return k
+		}
+	}
+	return 0
+}
+
+// CollapseGaps returns a new record with each run of consecutive gaps ('-')
+// in the sequence replaced by a single gap. All other bytes are copied
+// unchanged. The header is carried over.
+func (rec *Record) CollapseGaps() *Record {
+	seq := make([]byte, 0, len(rec.Sequence))
+	for i, c := range rec.Sequence {
+		if c == '-' && i > 0 && rec.Sequence[i-1] == '-' {
+			continue
+		}
+		seq = append(seq, c)
+	}
+	return &Record{Header: rec.Header, Sequence: seq}
+}
+
+// MaxExpansions is the maximum number of sequences Record.Expand produces.
+var MaxExpansions = 1024
+
+// ambiguity maps each uppercase IUPAC nucleotide code to the bases it
+// stands for.
+var ambiguity = map[byte]string{
+	'R': "AG", 'Y': "CT", 'S': "CG", 'W': "AT", 'K': "GT", 'M': "AC",
+	'B': "CGT", 'D': "AGT", 'H': "ACT", 'V': "ACG", 'N': "ACGT",
+}
+
+// Expand returns a record for each concrete sequence the IUPAC ambiguity
+// codes in the sequence stand for, i.e. the Cartesian product of the bases of
+// each code. Case is preserved and other bytes are copied unchanged. Headers
+// are suffixed with "_1", "_2", etc. An error is returned if the expansion
+// would produce more than MaxExpansions sequences.
+func (rec *Record) Expand() ([]*Record, error) {
+	total := 1
+	for _, c := range rec.Sequence {
+		if bases, ok := ambiguity[c&^0x20]; ok {
+			total *= len(bases)
+			if total > MaxExpansions {
+				return nil, errors.New("fasta: too many expansions")
+			}
+		}
+	}
+
+	seqs := [][]byte{nil}
+	for _, c := range rec.Sequence {
+		bases, ok := ambiguity[c&^0x20]
+		if !ok {
+			for i := range seqs {
+				seqs[i] = append(seqs[i], c)
+			}
+			continue
+		}
+		next := make([][]byte, 0, len(seqs)*len(bases))
+		for _, seq := range seqs {
+			for j := 0; j < len(bases); j++ {
+				b := bases[j] | c&0x20
+				next = append(next, append(seq[:len(seq):len(seq)], b))
+			}
+		}
+		seqs = next
+	}
+
+	recs := make([]*Record, len(seqs))
+	for i, seq := range seqs {
+		if seq == nil {
+			seq = make([]byte, 0)
+		}
+		recs[i] = &Record{Header: fmt.Sprintf("%s_%d", rec.Header, i+1), Sequence: seq}
+	}
+	return recs, nil
+}
+
+// kmerSet returns the set of distinct k-mers of seq, folded to uppercase.
+func kmerSet(seq []byte, k int) map[string]struct{} {
+	set := make(map[string]struct{})
+	if k <= 0 {
+		return set
+	}
+	upper := bytes.ToUpper(seq)
+	for i := 0; i+k <= len(upper); i++ {
+		set[string(upper[i:i+k])] = struct{}{}
+	}
+	return set
+}
+
+// KmerJaccard returns the Jaccard similarity of the sets of k-mers of the
+// sequences of a and b, i.e. the number of k-mers they share divided by the
+// number of distinct k-mers in either. It uses set semantics: each distinct
+// k-mer counts once regardless of how many times it occurs. K-mers are
+// compared case-insensitively. It returns 0 if either sequence has no k-mers.
+func KmerJaccard(a, b *Record, k int) float64 {
+	sa, sb := kmerSet(a.Sequence, k), kmerSet(b.Sequence, k)
+	if len(sa) == 0 || len(sb) == 0 {
+		return 0
+	}
+	var shared int
+	for kmer := range sa {
+		if _, ok := sb[kmer]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(sa)+len(sb)-shared)
+}
+
+// Subseq returns a new record with the bases of the sequence in the 0-based
+// half-open range [start, end) and the header annotated with the range, e.g.
+// "chr1:100-200". The bases are copied. It returns an error if the range is
+// not within the sequence.
+func (rec *Record) Subseq(start, end int) (*Record, error) {
+	if start < 0 || end > len(rec.Sequence) || start > end {
+		return nil, fmt.Errorf("fasta: invalid range %d-%d for sequence of length %d", start, end, len(rec.Sequence))
+	}
+	return &Record{
+		Header:   fmt.Sprintf("%s:%d-%d", rec.Header, start, end),
+		Sequence: append([]byte{}, rec.Sequence[start:end]...),
+	}, nil
+}
+
+// SplitN splits the sequence into n contiguous parts of equal size, the last
+// of which also absorbs the remainder. Each part is returned as a new record
+// with the header annotated with its 0-based half-open coordinates, e.g.
+// "chr1:100-200". n is clamped to the sequence length so that no part is
+// empty. SplitN returns nil if n <= 0 or the sequence is empty.
+func (rec *Record) SplitN(n int) []*Record {
+	if n > len(rec.Sequence) {
+		n = len(rec.Sequence)
+	}
+	if n <= 0 {
+		return nil
+	}
+	size := len(rec.Sequence) / n
+	parts := make([]*Record, n)
+	for i := range parts {
+		start, end := i*size, (i+1)*size
+		if i == n-1 {
+			end = len(rec.Sequence)
+		}
+		parts[i] = &Record{
+			Header:   fmt.Sprintf("%s:%d-%d", rec.Header, start, end),
+			Sequence: append([]byte(nil), rec.Sequence[start:end]...),
+		}
+	}
+	return parts
+}
+
+// Positions returns the 0-based positions in the sequence where b occurs. If
+// caseInsensitive is true, occurrences of both the upper and lower case of a
+// letter b are returned.
+func (rec *Record) Positions(b byte, caseInsensitive bool) []int {
+	other := b
+	if caseInsensitive {
+		switch {
+		case 'a' <= b && b <= 'z':
+			other = b - 'a' + 'A'
+		case 'A' <= b && b <= 'Z':
+			other = b - 'A' + 'a'
+		}
+	}
+	var pos []int
+	for i, c := range rec.Sequence {
+		if c == b || c == other {
+			pos = append(pos, i)
+		}
+	}
+	return pos
+}
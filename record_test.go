@@ -0,0 +1,375 @@
+package fasta
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Test composition
+var compositionTests = []struct {
+	Test   string
+	Seq    string
+	Counts map[byte]int
+	GC     float64
+}{
+	{"empty", "", map[byte]int{}, 0},
+	{"mixed case", "ACgt", map[byte]int{'A': 1, 'C': 1, 'G': 1, 'T': 1}, 0.5},
+	{"with ns", "GGNNcA--", map[byte]int{'G': 2, 'N': 2, 'C': 1, 'A': 1, '-': 2}, 0.75},
+	{"all ambiguous", "NNnn", map[byte]int{'N': 4}, 0},
+}
+
+func TestComposition(t *testing.T) {
+	for _, tt := range compositionTests {
+		rec := &Record{Header: "Seq1", Sequence: []byte(tt.Seq)}
+		if rec.Length() != len(tt.Seq) {
+			t.Errorf("%s: length=%d want %d", tt.Test, rec.Length(), len(tt.Seq))
+		}
+		if counts := rec.BaseCounts(); !reflect.DeepEqual(counts, tt.Counts) {
+			t.Errorf("%s: counts=%v want %v", tt.Test, counts, tt.Counts)
+		}
+		if gc := rec.GCContent(); gc != tt.GC {
+			t.Errorf("%s: gc=%v want %v", tt.Test, gc, tt.GC)
+		}
+	}
+}
+
+func TestMasking(t *testing.T) {
+	rec := &Record{Header: "Seq1", Sequence: []byte("ACgtNn-acGT")}
+	var tests = []struct {
+		Test string
+		Rec  *Record
+		Seq  string
+	}{
+		{"upper", rec.ToUpper(), "ACGTNN-ACGT"},
+		{"lower", rec.ToLower(), "acgtnn-acgt"},
+		{"hard mask", rec.HardMask(), "ACNNNN-NNGT"},
+	}
+
+	for _, tt := range tests {
+		if tt.Rec.Name() != rec.Name() {
+			t.Errorf("%s: header=%q want %q", tt.Test, tt.Rec.Name(), rec.Name())
+		}
+		if string(tt.Rec.Seq()) != tt.Seq {
+			t.Errorf("%s: seq=%q want %q", tt.Test, string(tt.Rec.Seq()), tt.Seq)
+		}
+	}
+	if string(rec.Seq()) != "ACgtNn-acGT" {
+		t.Errorf("record modified to %q", string(rec.Seq()))
+	}
+}
+
+// Test Complement
+var complementTests = []struct {
+	Test string
+	Seq  string
+	Comp string
+}{
+	{"empty", "", ""},
+	{"bases", "ACGT", "TGCA"},
+	{"mixed case", "AcgT", "TgcA"},
+	{"uracil", "ACGU", "TGCA"},
+	{"ambiguity", "RYKMSWBVDHN-", "YRMKSWVBHDN-"},
+	{"unknown", "A@T", "T@A"},
+}
+
+func TestComplement(t *testing.T) {
+	for _, tt := range complementTests {
+		rec := &Record{Header: "Seq1", Sequence: []byte(tt.Seq)}
+		comp := rec.Complement()
+
+		if comp.Name() != rec.Name() {
+			t.Errorf("%s: header=%q want %q", tt.Test, comp.Name(), rec.Name())
+		}
+		if string(comp.Seq()) != tt.Comp {
+			t.Errorf("%s: seq=%q want %q", tt.Test, string(comp.Seq()), tt.Comp)
+		}
+		if string(rec.Seq()) != tt.Seq {
+			t.Errorf("%s: receiver modified to %q", tt.Test, string(rec.Seq()))
+		}
+	}
+}
+
+func TestReverse(t *testing.T) {
+	rec := &Record{Header: "Seq1", Sequence: []byte("AACGt")}
+	if rev := rec.Reverse(); string(rev.Seq()) != "tGCAA" {
+		t.Errorf("seq=%q want %q", string(rev.Seq()), "tGCAA")
+	}
+	if rc := rec.Complement().Reverse(); string(rc.Seq()) != "aCGTT" {
+		t.Errorf("seq=%q want %q", string(rc.Seq()), "aCGTT")
+	}
+}
+
+// Test ReverseComplement
+var reverseComplementTests = []struct {
+	Test string
+	Seq  string
+	Err  string
+	RC   string
+}{
+	{Test: "empty", Seq: "", RC: ""},
+	{Test: "bases", Seq: "AACG", RC: "CGTT"},
+	{Test: "mixed case", Seq: "AAcgT", RC: "AcgTT"},
+	{Test: "ambiguity", Seq: "RYSWKMBDHVN-", RC: "-NBDHVKMWSRY"},
+	{Test: "invalid", Seq: "AC@T", Err: "fasta: invalid nucleotide '@' at position 2"},
+}
+
+func TestReverseComplement(t *testing.T) {
+	for _, tt := range reverseComplementTests {
+		rec := &Record{Header: "Seq1", Sequence: []byte(tt.Seq)}
+		rc, err := rec.ReverseComplement()
+
+		if tt.Err != "" {
+			if err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		if rc.Name() != rec.Name() {
+			t.Errorf("%s: header=%q want %q", tt.Test, rc.Name(), rec.Name())
+		}
+		if string(rc.Seq()) != tt.RC {
+			t.Errorf("%s: seq=%q want %q", tt.Test, string(rc.Seq()), tt.RC)
+		}
+		if cr := rec.Complement().Reverse(); string(cr.Seq()) != string(rc.Seq()) {
+			t.Errorf("%s: Complement().Reverse()=%q want %q", tt.Test, string(cr.Seq()), string(rc.Seq()))
+		}
+	}
+}
+
+// Test CircularOverlap
+var circularOverlapTests = []struct {
+	Test    string
+	Seq     string
+	Max     int
+	Overlap int
+}{
+	{"empty", "", 10, 0},
+	{"none", "ACGTTT", 10, 0},
+	{"overlap", "ACGTTTACG", 10, 3},
+	{"capped", "ACGTTTACG", 2, 0},
+	{"capped shorter", "AAGTTTAA", 1, 1},
+	{"homopolymer", "AAAA", 10, 3},
+	{"zero max", "ACGTTTACG", 0, 0},
+}
+
+func TestCircularOverlap(t *testing.T) {
+	for _, tt := range circularOverlapTests {
+		rec := &Record{Header: "Seq1", Sequence: []byte(tt.Seq)}
+		if n := rec.CircularOverlap(tt.Max); n != tt.Overlap {
+			t.Errorf("%s: overlap=%d want %d", tt.Test, n, tt.Overlap)
+		}
+	}
+}
+
+// Test CollapseGaps
+var collapseGapsTests = []struct {
+	Test      string
+	Seq       string
+	Collapsed string
+}{
+	{"empty", "", ""},
+	{"no gaps", "ACgt", "ACgt"},
+	{"single gaps", "A-C-g", "A-C-g"},
+	{"runs", "---A--Cg----t-", "-A-Cg-t-"},
+	{"all gaps", "-----", "-"},
+}
+
+func TestCollapseGaps(t *testing.T) {
+	for _, tt := range collapseGapsTests {
+		rec := &Record{Header: "Seq1", Sequence: []byte(tt.Seq)}
+		out := rec.CollapseGaps()
+		if string(out.Seq()) != tt.Collapsed {
+			t.Errorf("%s: seq=%q want %q", tt.Test, string(out.Seq()), tt.Collapsed)
+		}
+		if string(rec.Seq()) != tt.Seq {
+			t.Errorf("%s: receiver modified to %q", tt.Test, string(rec.Seq()))
+		}
+	}
+}
+
+// Test Expand
+var expandTests = []struct {
+	Test string
+	Seq  string
+	Max  int
+	Err  string
+	Seqs []string
+}{
+	{Test: "empty", Seq: "", Max: 10, Seqs: []string{""}},
+	{Test: "concrete", Seq: "ACGT", Max: 10, Seqs: []string{"ACGT"}},
+	{Test: "one code", Seq: "ARg", Max: 10, Seqs: []string{"AAg", "AGg"}},
+	{Test: "lowercase", Seq: "Ay-", Max: 10, Seqs: []string{"Ac-", "At-"}},
+	{Test: "product", Seq: "RBN", Max: 24, Seqs: []string{
+		"ACA", "ACC", "ACG", "ACT", "AGA", "AGC", "AGG", "AGT", "ATA", "ATC", "ATG", "ATT",
+		"GCA", "GCC", "GCG", "GCT", "GGA", "GGC", "GGG", "GGT", "GTA", "GTC", "GTG", "GTT",
+	}},
+	{Test: "too many", Seq: "RBN", Max: 23, Err: "fasta: too many expansions"},
+}
+
+func TestExpand(t *testing.T) {
+	defer func(max int) { MaxExpansions = max }(MaxExpansions)
+
+	for _, tt := range expandTests {
+		MaxExpansions = tt.Max
+		rec := &Record{Header: "Seq1", Sequence: []byte(tt.Seq)}
+		recs, err := rec.Expand()
+
+		if tt.Err != "" {
+			if err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		var seqs []string
+		for i, r := range recs {
+			if want := fmt.Sprintf("Seq1_%d", i+1); r.Name() != want {
+				t.Errorf("%s: header=%q want %q", tt.Test, r.Name(), want)
+			}
+			seqs = append(seqs, string(r.Seq()))
+		}
+		if !reflect.DeepEqual(seqs, tt.Seqs) {
+			t.Errorf("%s: seqs=%q want %q", tt.Test, seqs, tt.Seqs)
+		}
+	}
+}
+
+// Test KmerJaccard
+var kmerJaccardTests = []struct {
+	Test string
+	A, B string
+	K    int
+	J    float64
+}{
+	{"identical", "ACGTAC", "ACGTAC", 3, 1},
+	{"case", "ACGTAC", "acgtac", 3, 1},
+	{"disjoint", "AAAA", "CCCC", 2, 0},
+	{"partial", "ACGT", "CGTA", 2, 0.5},
+	{"multiset ignored", "AAAAAC", "AAC", 2, 1},
+	{"too short", "AC", "ACGT", 3, 0},
+	{"zero k", "ACGT", "ACGT", 0, 0},
+}
+
+func TestKmerJaccard(t *testing.T) {
+	for _, tt := range kmerJaccardTests {
+		a := &Record{Header: "a", Sequence: []byte(tt.A)}
+		b := &Record{Header: "b", Sequence: []byte(tt.B)}
+		if j := KmerJaccard(a, b, tt.K); j != tt.J {
+			t.Errorf("%s: jaccard=%v want %v", tt.Test, j, tt.J)
+		}
+	}
+}
+
+// Test Subseq
+var subseqTests = []struct {
+	Test       string
+	Start, End int
+	Err        string
+	Header     string
+	Seq        string
+}{
+	{Test: "valid", Start: 1, End: 4, Header: "Seq1:1-4", Seq: "CGT"},
+	{Test: "whole", Start: 0, End: 6, Header: "Seq1:0-6", Seq: "ACGTAC"},
+	{Test: "empty", Start: 2, End: 2, Header: "Seq1:2-2", Seq: ""},
+	{Test: "end out of bounds", Start: 2, End: 7, Err: "fasta: invalid range 2-7 for sequence of length 6"},
+	{Test: "negative start", Start: -1, End: 2, Err: "fasta: invalid range -1-2 for sequence of length 6"},
+	{Test: "start after end", Start: 3, End: 2, Err: "fasta: invalid range 3-2 for sequence of length 6"},
+}
+
+func TestSubseq(t *testing.T) {
+	for _, tt := range subseqTests {
+		rec := &Record{Header: "Seq1", Sequence: []byte("ACGTAC")}
+		sub, err := rec.Subseq(tt.Start, tt.End)
+
+		if tt.Err != "" {
+			if err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		if sub.Name() != tt.Header {
+			t.Errorf("%s: header=%q want %q", tt.Test, sub.Name(), tt.Header)
+		}
+		if string(sub.Seq()) != tt.Seq {
+			t.Errorf("%s: seq=%q want %q", tt.Test, string(sub.Seq()), tt.Seq)
+		}
+
+		// The subsequence does not alias the record.
+		if len(sub.Sequence) > 0 {
+			sub.Sequence[0] = 'N'
+			if string(rec.Seq()) != "ACGTAC" {
+				t.Errorf("%s: record modified to %q", tt.Test, string(rec.Seq()))
+			}
+		}
+	}
+}
+
+// Test SplitN
+var splitNTests = []struct {
+	Test    string
+	Seq     string
+	N       int
+	Headers []string
+	Seqs    []string
+}{
+	{"zero", "ACGT", 0, nil, nil},
+	{"empty", "", 3, nil, nil},
+	{"one", "ACGT", 1, []string{"Seq1:0-4"}, []string{"ACGT"}},
+	{"even", "AACCGG", 3, []string{"Seq1:0-2", "Seq1:2-4", "Seq1:4-6"}, []string{"AA", "CC", "GG"}},
+	{"remainder", "AACCGGT", 3, []string{"Seq1:0-2", "Seq1:2-4", "Seq1:4-7"}, []string{"AA", "CC", "GGT"}},
+	{"clamped", "ACG", 5, []string{"Seq1:0-1", "Seq1:1-2", "Seq1:2-3"}, []string{"A", "C", "G"}},
+}
+
+func TestSplitN(t *testing.T) {
+	for _, tt := range splitNTests {
+		rec := &Record{Header: "Seq1", Sequence: []byte(tt.Seq)}
+		var headers, seqs []string
+		for _, p := range rec.SplitN(tt.N) {
+			headers = append(headers, p.Name())
+			seqs = append(seqs, string(p.Seq()))
+		}
+		if !reflect.DeepEqual(headers, tt.Headers) {
+			t.Errorf("%s: headers=%q want %q", tt.Test, headers, tt.Headers)
+		}
+		if !reflect.DeepEqual(seqs, tt.Seqs) {
+			t.Errorf("%s: seqs=%q want %q", tt.Test, seqs, tt.Seqs)
+		}
+	}
+}
+
+// Test Positions
+var positionsTests = []struct {
+	Test            string
+	Seq             string
+	B               byte
+	CaseInsensitive bool
+	Pos             []int
+}{
+	{"empty", "", 'A', false, nil},
+	{"none", "CCGT", 'A', false, nil},
+	{"case sensitive", "AaCA", 'A', false, []int{0, 3}},
+	{"case insensitive", "AaCA", 'A', true, []int{0, 1, 3}},
+	{"case insensitive lower", "AaCA", 'a', true, []int{0, 1, 3}},
+	{"non-letter", "A-C-", '-', true, []int{1, 3}},
+}
+
+func TestPositions(t *testing.T) {
+	for _, tt := range positionsTests {
+		rec := &Record{Header: "Seq1", Sequence: []byte(tt.Seq)}
+		if pos := rec.Positions(tt.B, tt.CaseInsensitive); !reflect.DeepEqual(pos, tt.Pos) {
+			t.Errorf("%s: positions=%v want %v", tt.Test, pos, tt.Pos)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package fasta
+
+import "io"
+
+// A Scanner provides a convenient interface for reading FASTA records.
+// Successive calls to Scan step through the records of the input; Record
+// returns the current one. Scanning stops at EOF or at the first error, in
+// which case Err returns the error. Unlike Reader.Read, reaching EOF is not
+// treated as an error.
+type Scanner struct {
+	r   *Reader
+	rec *Record
+	err error
+}
+
+// NewScanner returns a new scanner that reads from f.
+func NewScanner(f io.Reader) *Scanner {
+	return &Scanner{r: NewReader(f)}
+}
+
+// Scan advances the scanner to the next record, which will then be available
+// through Record. It returns false when there are no more records, either
+// because the end of the input was reached or an error occurred.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		s.rec = nil
+		return false
+	}
+	s.rec, s.err = s.r.Read()
+	return s.err == nil
+}
+
+// Record returns the most recent record read by Scan.
+func (s *Scanner) Record() *Record {
+	return s.rec
+}
+
+// Err returns the first non-EOF error encountered by the scanner.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
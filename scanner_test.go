@@ -0,0 +1,61 @@
+package fasta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test Scanner
+var scanTests = []struct {
+	Test    string
+	Data    string
+	Err     string
+	Headers []string
+}{
+	{
+		Test:    "empty",
+		Data:    "",
+		Headers: nil,
+	},
+	{
+		Test:    "2-seq",
+		Data:    ">Seq1\nAAA\n>Seq2\nCCC",
+		Headers: []string{"Seq1", "Seq2"},
+	},
+	{
+		Test:    "format error",
+		Data:    "AAA\n>Seq1\nCCC\n",
+		Err:     "fasta: format error at line 1: sequence before header",
+		Headers: nil,
+	},
+}
+
+func TestScanner(t *testing.T) {
+	for _, tt := range scanTests {
+		s := NewScanner(strings.NewReader(tt.Data))
+
+		var headers []string
+		for s.Scan() {
+			headers = append(headers, s.Record().Name())
+		}
+
+		if tt.Err != "" {
+			if err := s.Err(); err == nil || !strings.Contains(err.Error(), tt.Err) {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+		} else if err := s.Err(); err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+
+		if !reflect.DeepEqual(headers, tt.Headers) {
+			t.Errorf("%s: headers=%q want %q", tt.Test, headers, tt.Headers)
+		}
+		if s.Scan() {
+			t.Errorf("%s: Scan returned true after end", tt.Test)
+		}
+		if s.Record() != nil {
+			t.Errorf("%s: unexpected record after end", tt.Test)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package fasta
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ReadAll reads all records of f. It returns the records read and the first
+// error encountered other than io.EOF, along with the records read up to it.
+// An input without records yields an empty slice and a nil error.
+func ReadAll(f io.Reader) ([]*Record, error) {
+	recs := []*Record{}
+	r := NewReader(f)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			return recs, err
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// Count returns the number of records in f. Records are discarded as they
+// are counted.
+func Count(f io.Reader) (int, error) {
+	var n int
+	err := eachHeader(f, func([]byte) { n++ })
+	return n, err
+}
+
+// Sample returns up to n records of f chosen uniformly at random using
+// reservoir sampling. At most n records are held in memory at a time besides
+// the one being read. The same seed yields the same sample. If f has n or
+// fewer records, all are returned in input order.
+func Sample(f io.Reader, n int, seed int64) ([]*Record, error) {
+	var (
+		rng    = rand.New(rand.NewSource(seed))
+		sample = []*Record{}
+		seen   int64
+	)
+	r := NewReader(f)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return sample, nil
+		}
+		if err != nil {
+			return sample, err
+		}
+		seen++
+		if len(sample) < n {
+			sample = append(sample, rec)
+		} else if j := rng.Int63n(seen); j < int64(n) {
+			sample[j] = rec
+		}
+	}
+}
+
+// Headers returns the headers, without the leading '>', of all records in f
+// in order. Unlike reading with a Reader, sequences are skipped without being
+// retained which makes it considerably faster and lighter for building a
+// table of contents.
+func Headers(f io.Reader) ([]string, error) {
+	var headers []string
+	err := eachHeader(f, func(header []byte) {
+		headers = append(headers, string(header))
+	})
+	if _, ok := err.(*FormatError); ok {
+		return nil, err
+	}
+	return headers, err
+}
+
+// eachHeader calls fn with each header of f, without the leading '>', in
+// order. The header passed to fn is only valid until fn returns.
+func eachHeader(f io.Reader, fn func(header []byte)) error {
+	var (
+		br     = bufio.NewReader(f)
+		header []byte
+		seen   bool // whether a header was seen.
+		inLine bool // whether in the middle of a line.
+		inHead bool // whether in a header line.
+		lineNo int
+	)
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull && err != io.EOF {
+			return err
+		}
+
+		if !inLine {
+			lineNo++
+			trimmed := bytes.TrimLeftFunc(chunk, unicode.IsSpace)
+			switch {
+			case len(trimmed) > 0 && trimmed[0] == '>':
+				inHead, seen = true, true
+				header = append(header[:0], trimmed[1:]...)
+			case len(trimmed) > 0 && trimmed[0] == ';':
+				// Skip comment lines.
+			case len(trimmed) > 0 && !seen:
+				return &FormatError{Line: lineNo, Msg: "sequence before header", Err: ErrNoHeader}
+			}
+		} else if inHead {
+			header = append(header, chunk...)
+		}
+
+		inLine = err == bufio.ErrBufferFull
+		if !inLine && inHead {
+			// Trailing whitespace is trimmed as by Reader, leading
+			// whitespace was trimmed before the '>'.
+			fn(bytes.TrimRightFunc(header, unicode.IsSpace))
+			inHead = false
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// FilterRename reads the records of in, drops those with sequences shorter
+// than minLen and writes the rest to out renamed prefix1, prefix2, etc. in
+// order. It returns the number of records written and any error.
+func FilterRename(in io.Reader, out *Writer, minLen int, prefix string) (int, error) {
+	var n int
+	r := NewReader(in)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if len(rec.Sequence) < minLen {
+			continue
+		}
+		rec.Header = prefix + strconv.Itoa(n+1)
+		if _, err = out.Write(rec); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// SplitByKey reads the records of in and writes each into the file
+// dir/<key>.fa, where key is the result of keyFn on the record header.
+// Sequences are wrapped at width. Keys are sanitized for use as file names by
+// replacing bytes other than letters, digits, '.', '_' and '-' with '_'. Each
+// file is created on first use and kept open until all records are written.
+// Distinct keys that sanitize to the same file name are an error, so each
+// file holds the records of a single key. It returns the number of records
+// written for each key and any error.
+func SplitByKey(in io.Reader, dir string, keyFn func(header string) string, width int) (map[string]int, error) {
+	type output struct {
+		key string
+		f   *os.File
+		b   *bufio.Writer
+		w   *Writer
+	}
+	var (
+		counts  = make(map[string]int)
+		outputs = make(map[string]*output)
+	)
+	closeAll := func() error {
+		var err error
+		for _, o := range outputs {
+			if ferr := o.b.Flush(); err == nil {
+				err = ferr
+			}
+			if cerr := o.f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}
+
+	r := NewReader(in)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			closeAll()
+			return counts, err
+		}
+
+		key := keyFn(rec.Header)
+		name := sanitizeKey(key) + ".fa"
+		o, ok := outputs[name]
+		if !ok {
+			f, err := os.Create(filepath.Join(dir, name))
+			if err != nil {
+				closeAll()
+				return counts, err
+			}
+			b := bufio.NewWriter(f)
+			o = &output{key: key, f: f, b: b, w: NewWriter(b, width)}
+			outputs[name] = o
+		} else if o.key != key {
+			closeAll()
+			return counts, fmt.Errorf("fasta: split: keys %q and %q both map to file %q", o.key, key, name)
+		}
+		if _, err = o.w.Write(rec); err != nil {
+			closeAll()
+			return counts, err
+		}
+		counts[key]++
+	}
+	return counts, closeAll()
+}
+
+// sanitizeKey returns key with bytes other than letters, digits, '.', '_' and
+// '-' replaced by '_'. Empty keys and keys consisting only of dots, which
+// are not usable as file names, are prefixed with '_'.
+func sanitizeKey(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '_' || c == '-') {
+			b[i] = '_'
+		}
+	}
+	if strings.Trim(string(b), ".") == "" {
+		return "_" + string(b)
+	}
+	return string(b)
+}
+
+// CheckWidth checks that the sequences of f are wrapped at a uniform width.
+// The expected width is the length of the first sequence line of the first
+// record that spans more than one line. A record violates the width if any
+// of its sequence lines but the last is not exactly that width, or if its
+// last line is longer. It returns the expected width, or 0 if no record spans
+// more than one line, and the IDs, i.e. the header up to the first
+// whitespace, of the violating records in order.
+func CheckWidth(f io.Reader) (int, []string, error) {
+	type record struct {
+		id    string
+		lines []int
+	}
+	var (
+		br      = bufio.NewReader(f)
+		width   int
+		pending []record // records seen before the width is known.
+		bad     []string
+		rec     *record
+		lineNo  int
+	)
+	violates := func(rec record) bool {
+		for i, n := range rec.lines {
+			if i < len(rec.lines)-1 && n != width || n > width {
+				return true
+			}
+		}
+		return false
+	}
+	finish := func() {
+		if rec == nil {
+			return
+		}
+		if width == 0 && len(rec.lines) > 1 {
+			width = rec.lines[0]
+			for _, p := range pending {
+				if violates(p) {
+					bad = append(bad, p.id)
+				}
+			}
+			pending = nil
+		}
+		if width == 0 {
+			pending = append(pending, *rec)
+		} else if violates(*rec) {
+			bad = append(bad, rec.id)
+		}
+	}
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return 0, nil, err
+		}
+		lineNo++
+		line = bytes.TrimSpace(line)
+		switch {
+		case len(line) == 0 || line[0] == ';': // Skip empty and comment lines.
+		case line[0] == '>':
+			finish()
+			id, _ := splitHeader(string(line[1:]))
+			rec = &record{id: id}
+		case rec == nil:
+			return 0, nil, &FormatError{Line: lineNo, Msg: "sequence before header", Err: ErrNoHeader}
+		default:
+			rec.lines = append(rec.lines, len(line))
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	finish()
+	return width, bad, nil
+}
+
+// A JoinEntry locates the sequence of an input record within the sequence
+// written by JoinAllManifest.
+type JoinEntry struct {
+	Header string // header of the input record.
+	Start  int    // 0-based offset of the sequence in the joined sequence.
+	Length int    // length of the sequence.
+}
+
+// JoinAll reads the records of in and writes their sequences to out as a
+// single record with header name, inserting spacer between consecutive
+// sequences. It returns the number of records joined and any error. See
+// JoinAllManifest.
+func JoinAll(in io.Reader, out *Writer, name string, spacer []byte) (int, error) {
+	entries, err := JoinAllManifest(in, out, name, spacer)
+	return len(entries), err
+}
+
+// JoinAllManifest is like JoinAll but returns the location of each record
+// joined. Records are written as they are read so only one input record is
+// held in memory at a time besides the manifest. Nothing is written if in
+// has no records. Since the header is written before the sequence is known,
+// out must not have AppendChecksum set. On error, the part of the record not
+// yet passed to the underlying writer of out is discarded so out can be used
+// for further records. If part of the record was already passed, as happens
+// for sequences longer than about 64KB, that truncated record remains in the
+// output and is ended before the next record is written.
+func JoinAllManifest(in io.Reader, out *Writer, name string, spacer []byte) (entries []JoinEntry, err error) {
+	if out.AppendChecksum {
+		return nil, errors.New("fasta: join: AppendChecksum is not supported")
+	}
+	if err := checkSeparator(out.RecordSeparator); err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(name, "\r\n") {
+		return nil, fmt.Errorf("%w: contains newline", ErrInvalidHeader)
+	}
+	out.wrap = out.recordWidth(nil)
+
+	var (
+		newline = out.newline // pending newline of the previous record.
+		written int           // bytes of the record flushed to out.
+	)
+	defer func() {
+		if err != nil {
+			out.buf, out.pos = out.buf[:0], 0
+			// A truncated record already written is ended by the newline
+			// pending before the next header.
+			out.newline = newline || written > 0
+		}
+	}()
+
+	r := NewReader(in)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		if len(entries) == 0 {
+			if out.UniquifyHeaders {
+				name = out.unique(name)
+			}
+			_, err = out.writeHeader(name)
+		} else if err = out.checkSeq(spacer, out.pos); err == nil {
+			var k int
+			k, err = out.writeSeq(spacer)
+			written += k
+		}
+		if err == nil {
+			err = out.checkSeq(rec.Sequence, out.pos)
+		}
+		if err != nil {
+			return entries, err
+		}
+		start := out.pos
+		k, err := out.writeSeq(rec.Sequence)
+		if written += k; err != nil {
+			return entries, err
+		}
+		entries = append(entries, JoinEntry{Header: rec.Header, Start: start, Length: len(rec.Sequence)})
+	}
+	if len(entries) > 0 {
+		if _, err := out.endRecord(); err != nil {
+			return entries, err
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,536 @@
+package fasta
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// Test ReadAll and Count
+var readAllTests = []struct {
+	Test    string
+	Data    string
+	Err     string
+	Headers []string
+}{
+	{
+		Test:    "empty",
+		Data:    "",
+		Headers: []string{},
+	},
+	{
+		Test:    "2-seq",
+		Data:    ">Seq1\nAAA\n>Seq2\nCCC",
+		Headers: []string{"Seq1", "Seq2"},
+	},
+	{
+		Test:    "error after records",
+		Data:    ">Seq1\nAAA\n>Seq2\nCCC\n>Seq3\nGG",
+		Err:     "read failed",
+		Headers: []string{"Seq1", "Seq2"},
+	},
+}
+
+func TestReadAll(t *testing.T) {
+	for _, tt := range readAllTests {
+		var f io.Reader = strings.NewReader(tt.Data)
+		if tt.Err != "" {
+			f = io.MultiReader(f, iotest.ErrReader(errors.New(tt.Err)))
+		}
+
+		recs, err := ReadAll(f)
+		if tt.Err != "" {
+			if err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+
+		headers := []string{}
+		for _, rec := range recs {
+			headers = append(headers, rec.Name())
+		}
+		if !reflect.DeepEqual(headers, tt.Headers) {
+			t.Errorf("%s: headers=%q want %q", tt.Test, headers, tt.Headers)
+		}
+
+		if tt.Err == "" {
+			n, err := Count(strings.NewReader(tt.Data))
+			if err != nil {
+				t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			}
+			if n != len(tt.Headers) {
+				t.Errorf("%s: count=%d want %d", tt.Test, n, len(tt.Headers))
+			}
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	var in strings.Builder
+	for i := 0; i < 100; i++ {
+		in.WriteString(">Seq" + strconv.Itoa(i) + "\nACGT\n")
+	}
+	headers := func(recs []*Record) []string {
+		var h []string
+		for _, rec := range recs {
+			h = append(h, rec.Name())
+		}
+		return h
+	}
+
+	s1, err := Sample(strings.NewReader(in.String()), 10, 42)
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	s2, err := Sample(strings.NewReader(in.String()), 10, 42)
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	if len(s1) != 10 {
+		t.Errorf("len=%d want 10", len(s1))
+	}
+	if !reflect.DeepEqual(headers(s1), headers(s2)) {
+		t.Errorf("samples differ for same seed: %q, %q", headers(s1), headers(s2))
+	}
+	s3, err := Sample(strings.NewReader(in.String()), 10, 7)
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	if reflect.DeepEqual(headers(s1), headers(s3)) {
+		t.Errorf("samples equal for different seeds: %q", headers(s1))
+	}
+
+	// Fewer records than requested.
+	all, err := Sample(strings.NewReader(">a\nA\n>b\nC\n"), 10, 42)
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(headers(all), want) {
+		t.Errorf("headers=%q want %q", headers(all), want)
+	}
+}
+
+// Test Headers
+var headersTests = []struct {
+	Test    string
+	Data    string
+	Err     string
+	Headers []string
+}{
+	{
+		Test:    "empty",
+		Data:    "",
+		Headers: nil,
+	},
+	{
+		Test:    "2-seq",
+		Data:    ">Seq1 desc\nAAA\nBBB\n\n>Seq2\nCCC\n",
+		Headers: []string{"Seq1 desc", "Seq2"},
+	},
+	{
+		Test:    "comments",
+		Data:    ";comment\n>Seq1\n;comment\nAAA\n",
+		Headers: []string{"Seq1"},
+	},
+	{
+		Test:    "no newline",
+		Data:    ">Seq1\nAAA\n>Seq2",
+		Headers: []string{"Seq1", "Seq2"},
+	},
+	{
+		Test:    "empty header",
+		Data:    ">\nAAA\n>Seq2\r\nCCC\r\n",
+		Headers: []string{"", "Seq2"},
+	},
+	{
+		Test:    "spaces",
+		Data:    " >  Seq1 desc \t\nAAA\n",
+		Headers: []string{"  Seq1 desc"},
+	},
+	{
+		Test:    "long lines",
+		Data:    ">" + strings.Repeat("H", 10000) + "\n" + strings.Repeat("A", 10000) + ">\n>Seq2\n",
+		Headers: []string{strings.Repeat("H", 10000), "Seq2"},
+	},
+	{
+		Test: "format error",
+		Data: "AAA\n>Seq1\nBBB\n",
+		Err:  "fasta: format error at line 1: sequence before header",
+	},
+}
+
+func TestHeaders(t *testing.T) {
+	for _, tt := range headersTests {
+		headers, err := Headers(strings.NewReader(tt.Data))
+
+		if tt.Err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.Err) {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		if !reflect.DeepEqual(headers, tt.Headers) {
+			t.Errorf("%s: headers=%q want %q", tt.Test, headers, tt.Headers)
+		}
+
+		// Headers match those of a Reader.
+		recs, _ := ReadAll(strings.NewReader(tt.Data))
+		for i, rec := range recs {
+			if i < len(headers) && headers[i] != rec.Name() {
+				t.Errorf("%s: header=%q want Reader header %q", tt.Test, headers[i], rec.Name())
+			}
+		}
+	}
+}
+
+// Test FilterRename
+var filterRenameTests = []struct {
+	Test   string
+	Data   string
+	MinLen int
+	Prefix string
+	Err    string
+	Output string
+	Count  int
+}{
+	{
+		Test:   "empty",
+		Data:   "",
+		Prefix: "contig",
+		Output: "",
+	},
+	{
+		Test:   "keep all",
+		Data:   ">a\nAAA\n>b\nCC\n",
+		Prefix: "contig",
+		Output: ">contig1\nAAA\n>contig2\nCC\n",
+		Count:  2,
+	},
+	{
+		Test:   "filter",
+		Data:   ">a\nA\n>b\nCCC\n>c\nGG\n>d\nTTTT\n",
+		MinLen: 3,
+		Prefix: "ctg_",
+		Output: ">ctg_1\nCCC\n>ctg_2\nTTTT\n",
+		Count:  2,
+	},
+	{
+		Test:   "format error",
+		Data:   "AAA\n>a\nAAA\n",
+		Prefix: "c",
+		Err:    "fasta: format error at line 1: sequence before header",
+	},
+}
+
+func TestFilterRename(t *testing.T) {
+	for _, tt := range filterRenameTests {
+		b := &bytes.Buffer{}
+		n, err := FilterRename(strings.NewReader(tt.Data), NewWriter(b, 60), tt.MinLen, tt.Prefix)
+
+		if tt.Err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.Err) {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		if out := b.String(); out != tt.Output {
+			t.Errorf("%s: out=%q want %q", tt.Test, out, tt.Output)
+		}
+		if n != tt.Count {
+			t.Errorf("%s: n=%d want %d", tt.Test, n, tt.Count)
+		}
+	}
+}
+
+func TestSplitByKey(t *testing.T) {
+	dir := t.TempDir()
+	in := "" +
+		">human|chr1\nAAA\n" +
+		">mouse|chr1\nCCC\n" +
+		">human|chr2\nGGGGG\n" +
+		">../etc|x\nTTT\n" +
+		">|y\nNNN\n"
+	keyFn := func(header string) string { return strings.SplitN(header, "|", 2)[0] }
+
+	counts, err := SplitByKey(strings.NewReader(in), dir, keyFn, 3)
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+
+	wantCounts := map[string]int{"human": 2, "mouse": 1, "../etc": 1, "": 1}
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("counts=%v want %v", counts, wantCounts)
+	}
+
+	wantFiles := map[string]string{
+		"human.fa":  ">human|chr1\nAAA\n>human|chr2\nGGG\nGG\n",
+		"mouse.fa":  ">mouse|chr1\nCCC\n",
+		".._etc.fa": ">../etc|x\nTTT\n",
+		"_.fa":      ">|y\nNNN\n",
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	if len(entries) != len(wantFiles) {
+		t.Errorf("got %d files want %d", len(entries), len(wantFiles))
+	}
+	for name, want := range wantFiles {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: unexpected error %q", name, err.Error())
+			continue
+		}
+		if string(b) != want {
+			t.Errorf("%s: out=%q want %q", name, string(b), want)
+		}
+	}
+}
+
+// Test CheckWidth
+var checkWidthTests = []struct {
+	Test  string
+	Data  string
+	Err   string
+	Width int
+	Bad   []string
+}{
+	{
+		Test:  "empty",
+		Data:  "",
+		Width: 0,
+	},
+	{
+		Test:  "uniform",
+		Data:  ">Seq1 desc\nAAA\nAAA\nA\n>Seq2\nCCC\n>Seq3\nGG\n",
+		Width: 3,
+	},
+	{
+		Test:  "comments",
+		Data:  ">Seq1\n;c\nAAA\n;long comment\nAAA\nA\n",
+		Width: 3,
+	},
+	{
+		Test:  "single lines",
+		Data:  ">Seq1\nAAAA\n>Seq2\nCC\n",
+		Width: 0,
+	},
+	{
+		Test:  "violations",
+		Data:  ">Seq1\nAAAA\n>Seq2 x\nCCC\nCCC\n>Seq3\nGG\nGGG\n>Seq4\nTTT\nTT\nTTT\n>Seq5\nAAA\nA\n>Seq6\r\nAAA\r\nAAA\r\n",
+		Width: 3,
+		Bad:   []string{"Seq1", "Seq3", "Seq4"},
+	},
+	{
+		Test: "format error",
+		Data: "AAA\n>Seq1\n",
+		Err:  "fasta: format error at line 1: sequence before header",
+	},
+}
+
+func TestCheckWidth(t *testing.T) {
+	for _, tt := range checkWidthTests {
+		width, bad, err := CheckWidth(strings.NewReader(tt.Data))
+
+		if tt.Err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.Err) {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		if width != tt.Width {
+			t.Errorf("%s: width=%d want %d", tt.Test, width, tt.Width)
+		}
+		if !reflect.DeepEqual(bad, tt.Bad) {
+			t.Errorf("%s: bad=%q want %q", tt.Test, bad, tt.Bad)
+		}
+	}
+}
+
+// Test JoinAll
+var joinAllTests = []struct {
+	Test   string
+	Data   string
+	Spacer string
+	Err    string
+	Output string
+	Count  int
+}{
+	{
+		Test:   "empty",
+		Data:   "",
+		Spacer: "NN",
+		Output: "",
+	},
+	{
+		Test:   "one",
+		Data:   ">a\nAAA\n",
+		Spacer: "NN",
+		Output: ">joined\nAAA\n",
+		Count:  1,
+	},
+	{
+		Test:   "spacer",
+		Data:   ">a\nAAA\n>b\nCC\nC\n>c\nG\n",
+		Spacer: "NN",
+		Output: ">joined\nAAAN\nNCCC\nNNG\n",
+		Count:  3,
+	},
+	{
+		Test:   "no spacer",
+		Data:   ">a\nAAA\n>b\nCCC\n",
+		Output: ">joined\nAAAC\nCC\n",
+		Count:  2,
+	},
+	{
+		Test:  "format error",
+		Data:  "AAA\n>a\nAAA\n",
+		Err:   "fasta: format error at line 1: sequence before header",
+		Count: 0,
+	},
+}
+
+func TestJoinAll(t *testing.T) {
+	for _, tt := range joinAllTests {
+		b := &bytes.Buffer{}
+		n, err := JoinAll(strings.NewReader(tt.Data), NewWriter(b, 4), "joined", []byte(tt.Spacer))
+
+		if tt.Err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.Err) {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		} else if out := b.String(); out != tt.Output {
+			t.Errorf("%s: out=%q want %q", tt.Test, out, tt.Output)
+		}
+
+		if n != tt.Count {
+			t.Errorf("%s: n=%d want %d", tt.Test, n, tt.Count)
+		}
+	}
+}
+
+func TestJoinAllWriteAfterError(t *testing.T) {
+	var tests = []struct {
+		Test      string
+		Data      string
+		Spacer    string
+		ReadErr   bool
+		NoNewline bool
+		Output    string
+	}{
+		{"invalid spacer", ">a\nAAA\n>b\nGG\n", ">", false, false, ">x\nGG\n"},
+		{"invalid sequence", ">a\nAAA\n>b\nCCC>\n", "", false, false, ">x\nGG\n"},
+		{"read error", ">a\nAAA\n>b\nCC\n", "", true, false, ">x\nGG\n"},
+		{"pending newline", ">a\nAAA\n>b\nGG\n", ">", false, true, ">p\nA\n>x\nGG"},
+	}
+
+	for _, tt := range tests {
+		b := &bytes.Buffer{}
+		w := NewWriter(b, 3)
+		w.NoTrailingNewline = tt.NoNewline
+		if tt.NoNewline {
+			w.WriteString("p", "A")
+		}
+		in := io.Reader(strings.NewReader(tt.Data))
+		if tt.ReadErr {
+			in = io.MultiReader(strings.NewReader(tt.Data), iotest.ErrReader(errors.New("read failed")))
+		}
+		if _, err := JoinAll(in, w, "j", []byte(tt.Spacer)); err == nil {
+			t.Errorf("%s: JoinAll succeeded, want error", tt.Test)
+		}
+		if _, err := w.WriteString("x", "GG"); err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+		}
+		if out := b.String(); out != tt.Output {
+			t.Errorf("%s: out=%q want %q", tt.Test, out, tt.Output)
+		}
+	}
+
+	// A record longer than writeBufSize is partly written before the '>'
+	// following it starts a line at position 70020. The truncated record is
+	// ended before the next one.
+	long := strings.Repeat("A", 70000)
+	b := &bytes.Buffer{}
+	w := NewWriter(b, 60)
+	in := ">a\n" + long + "\n>b\n" + strings.Repeat("C", 20) + ">G\n"
+	if _, err := JoinAll(strings.NewReader(in), w, "joined", nil); err == nil {
+		t.Errorf("long record: JoinAll succeeded, want error")
+	}
+	if b.Len() == 0 {
+		t.Fatalf("long record: nothing written before the error")
+	}
+	if _, err := w.WriteString("next", "GGG"); err != nil {
+		t.Fatalf("long record: unexpected error %q", err.Error())
+	}
+	recs, err := ReadAll(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatalf("long record: unexpected error %q", err.Error())
+	}
+	if len(recs) != 2 || recs[0].Name() != "joined" || !strings.HasPrefix(string(recs[0].Seq()), long[:writeBufSize/2]) ||
+		recs[1].Name() != "next" || string(recs[1].Seq()) != "GGG" {
+		t.Errorf("long record: got %d records, want truncated joined and next GGG", len(recs))
+	}
+	if strings.Contains(string(recs[0].Seq()), ">") {
+		t.Errorf("long record: truncated sequence contains '>'")
+	}
+}
+
+func TestJoinAllManifest(t *testing.T) {
+	b := &bytes.Buffer{}
+	entries, err := JoinAllManifest(strings.NewReader(">a x\nAAA\n>b\n\n>c\nGG\n"), NewWriter(b, 4), "joined", []byte("NN"))
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	want := []JoinEntry{{"a x", 0, 3}, {"b", 5, 0}, {"c", 7, 2}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries=%v want %v", entries, want)
+	}
+	if want := ">joined\nAAAN\nNNNG\nG\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+
+	w := NewWriter(&bytes.Buffer{}, 4)
+	w.AppendChecksum = true
+	if _, err := JoinAll(strings.NewReader(">a\nAAA\n"), w, "joined", nil); err == nil {
+		t.Errorf("AppendChecksum: JoinAll succeeded, want error")
+	}
+}
+
+func TestSplitByKeyCollision(t *testing.T) {
+	dir := t.TempDir()
+	in := ">a/b\nAAA\n>a_b\nCCC\n"
+	keyFn := func(header string) string { return header }
+
+	counts, err := SplitByKey(strings.NewReader(in), dir, keyFn, 60)
+	want := `fasta: split: keys "a/b" and "a_b" both map to file "a_b.fa"`
+	if err == nil || err.Error() != want {
+		t.Errorf("error %v, want error %q", err, want)
+	}
+	if wantCounts := map[string]int{"a/b": 1}; !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("counts=%v want %v", counts, wantCounts)
+	}
+}
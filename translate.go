@@ -0,0 +1,59 @@
+package fasta
+
+import "fmt"
+
+// codonTables holds the amino acids of the supported NCBI genetic codes
+// indexed by table number. Codons are ordered as in the NCBI tables, with the
+// bases of each position in the order T, C, A, G.
+var codonTables = map[int]string{
+	1:  "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	2:  "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG",
+	11: "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+}
+
+// codonIndex returns the position of base c within a codon table order, or
+// -1 if c is not a concrete nucleotide.
+func codonIndex(c byte) int {
+	switch c {
+	case 'T', 't', 'U', 'u':
+		return 0
+	case 'C', 'c':
+		return 1
+	case 'A', 'a':
+		return 2
+	case 'G', 'g':
+		return 3
+	}
+	return -1
+}
+
+// Translate returns a new record with the sequence translated to protein
+// using the NCBI genetic code table, starting at frame (0, 1 or 2). Stop
+// codons translate to '*' and codons with bases other than A, C, G, T or U,
+// e.g. N, to 'X'. A trailing incomplete codon is dropped. Supported tables
+// are 1 (standard), 2 (vertebrate mitochondrial) and 11 (bacterial). The
+// header is carried over.
+func (rec *Record) Translate(table int, frame int) (*Record, error) {
+	aas, ok := codonTables[table]
+	if !ok {
+		return nil, fmt.Errorf("fasta: unsupported codon table %d", table)
+	}
+	if frame < 0 || frame > 2 {
+		return nil, fmt.Errorf("fasta: invalid frame %d", frame)
+	}
+
+	var n int
+	if frame < len(rec.Sequence) {
+		n = (len(rec.Sequence) - frame) / 3
+	}
+	prot := make([]byte, 0, n)
+	for i := frame; i+3 <= len(rec.Sequence); i += 3 {
+		b1, b2, b3 := codonIndex(rec.Sequence[i]), codonIndex(rec.Sequence[i+1]), codonIndex(rec.Sequence[i+2])
+		if b1 < 0 || b2 < 0 || b3 < 0 {
+			prot = append(prot, 'X')
+			continue
+		}
+		prot = append(prot, aas[16*b1+4*b2+b3])
+	}
+	return &Record{Header: rec.Header, Sequence: prot}, nil
+}
@@ -0,0 +1,50 @@
+package fasta
+
+import "testing"
+
+// Test Translate
+var translateTests = []struct {
+	Test  string
+	Seq   string
+	Table int
+	Frame int
+	Err   string
+	Prot  string
+}{
+	{Test: "empty", Seq: "", Table: 1, Prot: ""},
+	{Test: "standard", Seq: "ATGGCCTGGTAA", Table: 1, Prot: "MAW*"},
+	{Test: "lowercase rna", Seq: "augGCCugg", Table: 1, Prot: "MAW"},
+	{Test: "partial codon", Seq: "ATGGCCTG", Table: 1, Prot: "MA"},
+	{Test: "frame 1", Seq: "CATGGCCTAG", Table: 1, Frame: 1, Prot: "MA*"},
+	{Test: "frame 2", Seq: "CCATGGCC", Table: 1, Frame: 2, Prot: "MA"},
+	{Test: "frame beyond", Seq: "A", Table: 1, Frame: 2, Prot: ""},
+	{Test: "unknown", Seq: "ATGNNNGCRTAA", Table: 1, Prot: "MXX*"},
+	{Test: "mitochondrial", Seq: "TGAAGAATA", Table: 2, Prot: "W*M"},
+	{Test: "bacterial", Seq: "TGAAGAATA", Table: 11, Prot: "*RI"},
+	{Test: "invalid frame", Seq: "ATG", Table: 1, Frame: 3, Err: "fasta: invalid frame 3"},
+	{Test: "invalid table", Seq: "ATG", Table: 7, Err: "fasta: unsupported codon table 7"},
+}
+
+func TestTranslate(t *testing.T) {
+	for _, tt := range translateTests {
+		rec := &Record{Header: "Seq1", Sequence: []byte(tt.Seq)}
+		prot, err := rec.Translate(tt.Table, tt.Frame)
+
+		if tt.Err != "" {
+			if err == nil || err.Error() != tt.Err {
+				t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %q", tt.Test, err.Error())
+			continue
+		}
+
+		if prot.Name() != rec.Name() {
+			t.Errorf("%s: header=%q want %q", tt.Test, prot.Name(), rec.Name())
+		}
+		if string(prot.Seq()) != tt.Prot {
+			t.Errorf("%s: seq=%q want %q", tt.Test, string(prot.Seq()), tt.Prot)
+		}
+	}
+}
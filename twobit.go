@@ -0,0 +1,201 @@
+package fasta
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// twoBitSignature is the magic number at the start of a .2bit file.
+const twoBitSignature = 0x1A412743
+
+// twoBitBases maps each 2-bit code of the .2bit format to its base.
+var twoBitBases = [4]byte{'T', 'C', 'A', 'G'}
+
+// A TwoBitReader reads sequences from a UCSC .2bit encoded file. Blocks of Ns
+// are restored as 'N' and soft-masked blocks as lowercase bases. Sequences can
+// be read in file order with Read or accessed by name with Get.
+type TwoBitReader struct {
+	r       io.ReadSeeker
+	order   binary.ByteOrder
+	version uint32
+	names   []string
+	offsets map[string]uint64
+	size    int64 // size of the file, bounding the data read from it.
+	next    int
+}
+
+// NewTwoBitReader returns a new reader for the .2bit file in r. It reads the
+// file header and sequence index and returns an error if they are invalid.
+// Counts and sizes read from the file are checked against its size before
+// allocating memory for them so a corrupt file cannot exhaust memory.
+func NewTwoBitReader(r io.ReadSeeker) (*TwoBitReader, error) {
+	fileSize, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+
+	var head [16]byte
+	if _, err := io.ReadFull(br, head[:]); err != nil {
+		return nil, fmt.Errorf("fasta: 2bit: reading header: %v", err)
+	}
+	tr := &TwoBitReader{r: r, offsets: make(map[string]uint64), size: fileSize}
+	switch {
+	case binary.LittleEndian.Uint32(head[0:]) == twoBitSignature:
+		tr.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(head[0:]) == twoBitSignature:
+		tr.order = binary.BigEndian
+	default:
+		return nil, errors.New("fasta: 2bit: invalid signature")
+	}
+	tr.version = tr.order.Uint32(head[4:])
+	if tr.version > 1 {
+		return nil, fmt.Errorf("fasta: 2bit: unsupported version %d", tr.version)
+	}
+
+	// Each index entry holds at least a name length and a 4-byte offset.
+	count := tr.order.Uint32(head[8:])
+	if int64(count)*5 > fileSize-int64(len(head)) {
+		return nil, fmt.Errorf("fasta: 2bit: reading index: sequence count %d exceeds file size", count)
+	}
+	for i := uint32(0); i < count; i++ {
+		size, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("fasta: 2bit: reading index: %v", err)
+		}
+		name := make([]byte, size)
+		if _, err = io.ReadFull(br, name); err != nil {
+			return nil, fmt.Errorf("fasta: 2bit: reading index: %v", err)
+		}
+		var offset uint64
+		if tr.version == 0 {
+			var v uint32
+			err = binary.Read(br, tr.order, &v)
+			offset = uint64(v)
+		} else {
+			err = binary.Read(br, tr.order, &offset)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fasta: 2bit: reading index: %v", err)
+		}
+		if offset > uint64(fileSize) {
+			return nil, fmt.Errorf("fasta: 2bit: offset of %q exceeds file size", name)
+		}
+		tr.names = append(tr.names, string(name))
+		tr.offsets[string(name)] = offset
+	}
+	return tr, nil
+}
+
+// Names returns the names of the sequences in the file in file order.
+func (tr *TwoBitReader) Names() []string {
+	return tr.names
+}
+
+// Read returns the next sequence in file order. After the last sequence,
+// subsequent calls to Read will return a nil record and io.EOF.
+func (tr *TwoBitReader) Read() (*Record, error) {
+	if tr.next >= len(tr.names) {
+		return nil, io.EOF
+	}
+	rec, err := tr.Get(tr.names[tr.next])
+	if err != nil {
+		return nil, err
+	}
+	tr.next++
+	return rec, nil
+}
+
+// Get returns the sequence with the given name.
+func (tr *TwoBitReader) Get(name string) (*Record, error) {
+	offset, ok := tr.offsets[name]
+	if !ok {
+		return nil, fmt.Errorf("fasta: 2bit: unknown sequence %q", name)
+	}
+	if _, err := tr.r.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(tr.r)
+
+	var size uint32
+	if err := binary.Read(br, tr.order, &size); err != nil {
+		return nil, fmt.Errorf("fasta: 2bit: reading %q: %v", name, err)
+	}
+	left := tr.size - int64(offset) - 4 // bytes of the file left to read.
+	nBlocks, err := tr.readBlocks(br, left)
+	if err != nil {
+		return nil, fmt.Errorf("fasta: 2bit: reading %q: %v", name, err)
+	}
+	left -= 4 + 8*int64(len(nBlocks))
+	maskBlocks, err := tr.readBlocks(br, left)
+	if err != nil {
+		return nil, fmt.Errorf("fasta: 2bit: reading %q: %v", name, err)
+	}
+	left -= 4 + 8*int64(len(maskBlocks))
+	var reserved uint32
+	if err = binary.Read(br, tr.order, &reserved); err != nil {
+		return nil, fmt.Errorf("fasta: 2bit: reading %q: %v", name, err)
+	}
+	if left -= 4; (int64(size)+3)/4 > left {
+		return nil, fmt.Errorf("fasta: 2bit: reading %q: size %d exceeds file size", name, size)
+	}
+	packed := make([]byte, (uint64(size)+3)/4)
+	if _, err = io.ReadFull(br, packed); err != nil {
+		return nil, fmt.Errorf("fasta: 2bit: reading %q: %v", name, err)
+	}
+
+	seq := make([]byte, size)
+	for i := range seq {
+		code := packed[i/4] >> (6 - 2*uint(i%4)) & 3
+		seq[i] = twoBitBases[code]
+	}
+	for _, b := range nBlocks {
+		if b[0]+b[1] > uint64(size) {
+			return nil, fmt.Errorf("fasta: 2bit: reading %q: N block out of range", name)
+		}
+		for i := b[0]; i < b[0]+b[1]; i++ {
+			seq[i] = 'N'
+		}
+	}
+	for _, b := range maskBlocks {
+		if b[0]+b[1] > uint64(size) {
+			return nil, fmt.Errorf("fasta: 2bit: reading %q: mask block out of range", name)
+		}
+		for i := b[0]; i < b[0]+b[1]; i++ {
+			seq[i] |= 0x20
+		}
+	}
+	return &Record{Header: name, Sequence: seq}, nil
+}
+
+// readBlocks reads a block count followed by the block starts and the block
+// sizes. It returns the (start, size) pair of each block. It returns an error
+// if the blocks would not fit in the left bytes of the file.
+func (tr *TwoBitReader) readBlocks(r io.Reader, left int64) ([][2]uint64, error) {
+	var count uint32
+	if err := binary.Read(r, tr.order, &count); err != nil {
+		return nil, err
+	}
+	if 4+8*int64(count) > left {
+		return nil, fmt.Errorf("block count %d exceeds file size", count)
+	}
+	starts := make([]uint32, count)
+	if err := binary.Read(r, tr.order, starts); err != nil {
+		return nil, err
+	}
+	sizes := make([]uint32, count)
+	if err := binary.Read(r, tr.order, sizes); err != nil {
+		return nil, err
+	}
+	blocks := make([][2]uint64, count)
+	for i := range blocks {
+		blocks[i] = [2]uint64{uint64(starts[i]), uint64(sizes[i])}
+	}
+	return blocks, nil
+}
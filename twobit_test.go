@@ -0,0 +1,174 @@
+package fasta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+)
+
+// twoBitCodes maps each base to its 2-bit code.
+var twoBitCodes = map[byte]byte{'T': 0, 'C': 1, 'A': 2, 'G': 3}
+
+// encodeTwoBit returns the version 0 .2bit encoding of recs in byte order
+// order.
+func encodeTwoBit(order binary.ByteOrder, recs []*Record) []byte {
+	var index, data bytes.Buffer
+
+	headerSize := 16
+	for _, rec := range recs {
+		headerSize += 1 + len(rec.Header) + 4
+	}
+
+	for _, rec := range recs {
+		index.WriteByte(byte(len(rec.Header)))
+		index.WriteString(rec.Header)
+		binary.Write(&index, order, uint32(headerSize+data.Len()))
+
+		var nBlocks, maskBlocks [][2]uint32
+		for i, c := range rec.Sequence {
+			if c == 'N' || c == 'n' {
+				nBlocks = addTwoBitBlock(nBlocks, i)
+			}
+			if c >= 'a' {
+				maskBlocks = addTwoBitBlock(maskBlocks, i)
+			}
+		}
+
+		binary.Write(&data, order, uint32(len(rec.Sequence)))
+		for _, blocks := range [][][2]uint32{nBlocks, maskBlocks} {
+			binary.Write(&data, order, uint32(len(blocks)))
+			for _, b := range blocks {
+				binary.Write(&data, order, b[0])
+			}
+			for _, b := range blocks {
+				binary.Write(&data, order, b[1])
+			}
+		}
+		binary.Write(&data, order, uint32(0))
+
+		packed := make([]byte, (len(rec.Sequence)+3)/4)
+		for i, c := range rec.Sequence {
+			packed[i/4] |= twoBitCodes[c&^0x20] << (6 - 2*uint(i%4))
+		}
+		data.Write(packed)
+	}
+
+	var out bytes.Buffer
+	binary.Write(&out, order, []uint32{twoBitSignature, 0, uint32(len(recs)), 0})
+	out.Write(index.Bytes())
+	out.Write(data.Bytes())
+	return out.Bytes()
+}
+
+// addTwoBitBlock extends the last of blocks with position i if adjacent or
+// starts a new block.
+func addTwoBitBlock(blocks [][2]uint32, i int) [][2]uint32 {
+	if n := len(blocks); n > 0 && blocks[n-1][0]+blocks[n-1][1] == uint32(i) {
+		blocks[n-1][1]++
+		return blocks
+	}
+	return append(blocks, [2]uint32{uint32(i), 1})
+}
+
+var twoBitRecords = []*Record{
+	&Record{Header: "chr1", Sequence: []byte("ACGTNNNNacgtnnAC")},
+	&Record{Header: "chr2", Sequence: []byte("")},
+	&Record{Header: "chrM", Sequence: []byte("GGa")},
+}
+
+func TestTwoBitReader(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := encodeTwoBit(order, twoBitRecords)
+		tr, err := NewTwoBitReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: unexpected error %q", order, err.Error())
+		}
+
+		names := tr.Names()
+		if len(names) != len(twoBitRecords) {
+			t.Fatalf("%s: names=%q", order, names)
+		}
+
+		// Sequential access.
+		for i, want := range twoBitRecords {
+			rec, err := tr.Read()
+			if err != nil {
+				t.Fatalf("%s: unexpected error %q", order, err.Error())
+			}
+			if names[i] != want.Header || rec.Name() != want.Header {
+				t.Errorf("%s: header=%q want %q", order, rec.Name(), want.Header)
+			}
+			if string(rec.Seq()) != string(want.Sequence) {
+				t.Errorf("%s: seq=%q want %q", order, string(rec.Seq()), string(want.Sequence))
+			}
+		}
+		if rec, err := tr.Read(); rec != nil || err != io.EOF {
+			t.Errorf("%s: got %v, %v want nil, io.EOF", order, rec, err)
+		}
+
+		// Random access.
+		rec, err := tr.Get("chrM")
+		if err != nil {
+			t.Fatalf("%s: unexpected error %q", order, err.Error())
+		}
+		if string(rec.Seq()) != "GGa" {
+			t.Errorf("%s: seq=%q want %q", order, string(rec.Seq()), "GGa")
+		}
+		if _, err = tr.Get("chrX"); err == nil || !strings.Contains(err.Error(), "unknown sequence") {
+			t.Errorf("%s: error %v, want unknown sequence", order, err)
+		}
+	}
+}
+
+func TestTwoBitReaderErrors(t *testing.T) {
+	var tests = []struct {
+		Test string
+		Data []byte
+		Err  string
+	}{
+		{"empty", nil, "fasta: 2bit: reading header"},
+		{"signature", make([]byte, 16), "fasta: 2bit: invalid signature"},
+		{"version", []byte{0x43, 0x27, 0x41, 0x1A, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, "fasta: 2bit: unsupported version 2"},
+		{"truncated index", []byte{0x43, 0x27, 0x41, 0x1A, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}, "fasta: 2bit: reading index"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewTwoBitReader(bytes.NewReader(tt.Data))
+		if err == nil || !strings.Contains(err.Error(), tt.Err) {
+			t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+		}
+	}
+
+	data := encodeTwoBit(binary.LittleEndian, twoBitRecords)
+	tr, err := NewTwoBitReader(bytes.NewReader(data[:len(data)-2]))
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Error())
+	}
+	if _, err = tr.Get("chrM"); err == nil || !strings.Contains(err.Error(), `reading "chrM"`) {
+		t.Errorf("error %v, want truncated sequence error", err)
+	}
+
+	// Corrupt counts and sizes are rejected before allocating for them.
+	var corruptTests = []struct {
+		Test   string
+		Offset int
+		Err    string
+	}{
+		{"sequence count", 8, "fasta: 2bit: reading index: sequence count 4294967295 exceeds file size"},
+		{"sequence size", 25, `fasta: 2bit: reading "chr1": size 4294967295 exceeds file size`},
+		{"block count", 29, `fasta: 2bit: reading "chr1": block count 4294967295 exceeds file size`},
+	}
+	for _, tt := range corruptTests {
+		data := encodeTwoBit(binary.LittleEndian, []*Record{{Header: "chr1", Sequence: []byte("ACGT")}})
+		binary.LittleEndian.PutUint32(data[tt.Offset:], 0xFFFFFFFF)
+		tr, err := NewTwoBitReader(bytes.NewReader(data))
+		if err == nil {
+			_, err = tr.Get("chr1")
+		}
+		if err == nil || err.Error() != tt.Err {
+			t.Errorf("%s: error %v, want error %q", tt.Test, err, tt.Err)
+		}
+	}
+}